@@ -0,0 +1,106 @@
+package termdialog
+
+// ask opens a dialog and runs a nested event loop until it is closed, returning the result it
+// was closed with.
+func (dialogStack *DialogStack) ask(dialog Dialog) (result Result) {
+	dialogStack.Open(dialog)
+	dialogStack.runUntil(func() bool { return !dialogStack.IsOpen(dialog) })
+
+	// The loop also gives up if the backend stops delivering events, in which case the dialog
+	// is still open and is closed here as aborted.
+	if dialogStack.IsOpen(dialog) {
+		dialog.SetResult(Aborted)
+		dialogStack.Close(dialog)
+	}
+
+	return dialog.GetResult()
+}
+
+// Function AskInput opens an input dialog and blocks, processing events, until it is closed. The
+// entered value is returned if the dialog was confirmed (i.e. its callback, if any, returned true),
+// and an empty string otherwise.
+func (dialogStack *DialogStack) AskInput(dialog *InputDialog) (value string, result Result) {
+	callback := dialog.callback
+	defer func() { dialog.callback = callback }()
+
+	// Capture the value as it is handed to the callback, in case the dialog changes afterwards.
+	dialog.callback = func(v string, arg interface{}) bool {
+		shouldClose := callback == nil || callback(v, arg)
+		if shouldClose {
+			value = v
+		}
+		return shouldClose
+	}
+
+	result = dialogStack.ask(dialog)
+	if result != Confirmed {
+		value = ""
+	}
+	return value, result
+}
+
+// Function AskTextArea opens a text area dialog and blocks, processing events, until it is closed.
+// The text is returned if the dialog was confirmed (i.e. its callback, if any, returned true), and
+// an empty string otherwise.
+func (dialogStack *DialogStack) AskTextArea(dialog *TextAreaDialog) (value string, result Result) {
+	callback := dialog.callback
+	defer func() { dialog.callback = callback }()
+
+	dialog.callback = func(v string, arg interface{}) bool {
+		shouldClose := callback == nil || callback(v, arg)
+		if shouldClose {
+			value = v
+		}
+		return shouldClose
+	}
+
+	result = dialogStack.ask(dialog)
+	if result != Confirmed {
+		value = ""
+	}
+	return value, result
+}
+
+// Function AskSelection opens a selection dialog and blocks, processing events, until it is
+// closed. The chosen option is returned if the dialog was confirmed (i.e. the option's callback, if
+// any, returned true), and nil otherwise.
+func (dialogStack *DialogStack) AskSelection(dialog *SelectionDialog) (option *Option, result Result) {
+	result = dialogStack.ask(dialog)
+	if result != Confirmed {
+		return nil, result
+	}
+	return dialog.GetSelectedOption(), result
+}
+
+// Function AskChecklist opens a checklist dialog and blocks, processing events, until it is closed.
+// The checked options are returned if the dialog was confirmed (i.e. its callback, if any, returned
+// true), and nil otherwise.
+func (dialogStack *DialogStack) AskChecklist(dialog *ChecklistDialog) (checked []*Option, result Result) {
+	callback := dialog.callback
+	defer func() { dialog.callback = callback }()
+
+	dialog.callback = func(c []*Option, arg interface{}) bool {
+		shouldClose := callback == nil || callback(c, arg)
+		if shouldClose {
+			checked = c
+		}
+		return shouldClose
+	}
+
+	result = dialogStack.ask(dialog)
+	if result != Confirmed {
+		checked = nil
+	}
+	return checked, result
+}
+
+// Function AskRadioList opens a radio list dialog and blocks, processing events, until it is
+// closed. The marked option is returned if the dialog was confirmed (i.e. its callback, if any,
+// returned true), and nil otherwise.
+func (dialogStack *DialogStack) AskRadioList(dialog *RadioListDialog) (marked *Option, result Result) {
+	result = dialogStack.ask(dialog)
+	if result != Confirmed {
+		return nil, result
+	}
+	return dialog.GetMarkedOption(), result
+}
@@ -0,0 +1,170 @@
+package termdialog
+
+import (
+	"fmt"
+	"github.com/nsf/termbox-go"
+	"os"
+	"sync"
+)
+
+// Type Backend represents a surface that dialogs are rendered onto and that input events are read
+// from. Cells are addressed from the top-left corner of the screen; calling SetCursor(-1, -1)
+// hides the cursor.
+type Backend interface {
+	SetCell(x int, y int, ch rune, fg termbox.Attribute, bg termbox.Attribute)
+	Size() (width int, height int)
+	Clear(fg termbox.Attribute, bg termbox.Attribute) error
+	Flush() error
+	PollEvent() termbox.Event
+	SetCursor(x int, y int)
+
+	// Interrupt causes a PollEvent that is in progress (or, if there is none, the next one) to
+	// return an EventInterrupt event. It may be called from any goroutine.
+	Interrupt()
+}
+
+// Type CombiningBackend is implemented by backends that can draw combining marks (such as the
+// accent in a decomposed "é") in the same cell as the character they modify. With other backends,
+// only the base character is drawn.
+type CombiningBackend interface {
+	SetCellCombining(x int, y int, ch rune, combining []rune, fg termbox.Attribute, bg termbox.Attribute)
+}
+
+// Constant ModCtrl is set in Event.Mod by backends that can report the Ctrl key being held down
+// together with keys that have no control code of their own, such as the arrow keys. termbox
+// itself never reports it.
+const ModCtrl termbox.Modifier = 1 << 7
+
+// Constants EventPasteStart and EventPasteEnd are event types, in addition to termbox's, that
+// backends supporting bracketed paste deliver before and after the keypresses that make up pasted
+// text. termbox itself never reports them, but TermboxBackend does once bracketed paste has been
+// enabled with EnablePaste; without it, a paste is just a series of keypresses.
+const (
+	EventPasteStart termbox.EventType = 0x40 + iota
+	EventPasteEnd
+)
+
+// Type TermboxBackend is a Backend that renders through termbox. The application remains
+// responsible for calling termbox.Init and termbox.Close, and EnablePaste and DisablePaste if it
+// wants pastes delivered in one piece; InitBackend does all of this.
+//
+// termbox is left in its default InputEsc input mode, in which it reports neither ModAlt nor keys
+// such as Ctrl+Left; the backend recognises them itself, from the Esc that termbox reports at the
+// start of them and the keys that immediately follow it. A lone Esc is therefore delivered after
+// a short delay.
+type TermboxBackend struct {
+	interrupts     chan struct{}
+	interruptsOnce sync.Once
+	input          termboxInput
+}
+
+func (backend *TermboxBackend) SetCell(x int, y int, ch rune, fg termbox.Attribute, bg termbox.Attribute) {
+	termbox.SetCell(x, y, ch, fg, bg)
+}
+
+func (backend *TermboxBackend) Size() (width int, height int) {
+	return termbox.Size()
+}
+
+func (backend *TermboxBackend) Clear(fg termbox.Attribute, bg termbox.Attribute) (err error) {
+	return termbox.Clear(fg, bg)
+}
+
+func (backend *TermboxBackend) Flush() (err error) {
+	return termbox.Flush()
+}
+
+func (backend *TermboxBackend) PollEvent() (event termbox.Event) {
+	return backend.input.PollEvent()
+}
+
+func (backend *TermboxBackend) SetCursor(x int, y int) {
+	termbox.SetCursor(x, y)
+}
+
+// Function EnablePaste turns on the terminal's bracketed paste mode, in which it marks the start and
+// end of pasted text so that the backend can report them as EventPasteStart and EventPasteEnd. It
+// should be called after termbox.Init, and DisablePaste before termbox.Close.
+func (backend *TermboxBackend) EnablePaste() (err error) {
+	return writeTerminal("\x1b[?2004h")
+}
+
+// Function DisablePaste turns the terminal's bracketed paste mode off again.
+func (backend *TermboxBackend) DisablePaste() (err error) {
+	return writeTerminal("\x1b[?2004l")
+}
+
+// writeTerminal writes a control sequence straight to the terminal, which termbox gives no way to
+// do. Like termbox, it writes to /dev/tty rather than to standard output.
+func writeTerminal(sequence string) (err error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+
+	_, err = tty.WriteString(sequence)
+	if closeErr := tty.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (backend *TermboxBackend) Interrupt() {
+	// termbox.Interrupt blocks until PollEvent receives the interrupt, so interrupts are handed
+	// to a separate goroutine. Requests made while one is already waiting to be sent are merged.
+	backend.interruptsOnce.Do(func() {
+		backend.interrupts = make(chan struct{}, 1)
+		go func() {
+			for range backend.interrupts {
+				termbox.Interrupt()
+			}
+		}()
+	})
+
+	select {
+	case backend.interrupts <- struct{}{}:
+	default:
+	}
+}
+
+// Variable CurrentBackend is the backend that all dialogs and drawing functions render through.
+// It defaults to termbox, and can be replaced before a DialogStack is run.
+var CurrentBackend Backend = &TermboxBackend{}
+
+// Function InitBackend initialises the named backend ("termbox" or "tcell") and makes it the
+// CurrentBackend. If name is empty, the TERMDIALOG_BACKEND environment variable is consulted, and
+// termbox is used if that is unset too. The returned function shuts the backend down again and
+// should be deferred by the caller.
+func InitBackend(name string) (closeBackend func(), err error) {
+	if name == "" {
+		name = os.Getenv("TERMDIALOG_BACKEND")
+	}
+
+	switch name {
+	case "", "termbox":
+		err = termbox.Init()
+		if err != nil {
+			return nil, err
+		}
+
+		backend := &TermboxBackend{}
+		backend.EnablePaste() // If this fails, pastes are typed a character at a time instead.
+
+		CurrentBackend = backend
+		return func() {
+			backend.DisablePaste()
+			termbox.Close()
+		}, nil
+
+	case "tcell":
+		backend, err := NewTcellBackend()
+		if err != nil {
+			return nil, err
+		}
+
+		CurrentBackend = backend
+		return backend.Close, nil
+	}
+
+	return nil, fmt.Errorf("termdialog: unknown backend %q", name)
+}
@@ -0,0 +1,137 @@
+package termdialog
+
+import (
+	"github.com/nsf/termbox-go"
+)
+
+/*
+  +-------------+
+  |             |
+  |  Title      |
+  |             |
+  |  [x] xxx    |
+  |  [ ] yyy    |
+  |  [x] zzz    |
+  |             |
+  +-------------+
+*/
+
+// Type ChecklistDialog represents a dialog in which any number of options can be checked. Space
+// toggles the selected option, 'a' checks every option, 'i' inverts which options are checked, and
+// Enter submits the checked options. If a filter mode is set, letters are typed into the filter
+// instead, so 'a' and 'i' are not available. The options' own callbacks are not called.
+type ChecklistDialog struct {
+	SelectionDialog
+	checked  map[*Option]bool
+	callback func([]*Option, interface{}) bool
+	arg      interface{}
+}
+
+// Function NewChecklistDialog creates and returns a new checklist dialog, with no options checked.
+// The callback is called with the checked options (in the order they appear in the dialog) when
+// Enter is pressed; if it returns false, the dialog stays open. The callback can be nil. Set
+// maxVisibleOptions to get scrolling, as for NewSelectionDialog.
+func NewChecklistDialog(title string, options []*Option, callback func(checked []*Option, arg interface{}) bool, arg interface{}, maxVisibleOptions ...int) (dialog *ChecklistDialog) {
+	dialog = &ChecklistDialog{
+		SelectionDialog: *NewSelectionDialog(title, options, maxVisibleOptions...),
+		checked:         make(map[*Option]bool),
+		callback:        callback,
+		arg:             arg,
+	}
+
+	dialog.itemPrefix = dialog.checkbox
+	return dialog
+}
+
+func (dialog *ChecklistDialog) checkbox(option *Option) (prefix string) {
+	if dialog.checked[option] {
+		return "[x] "
+	}
+	return "[ ] "
+}
+
+func (dialog *ChecklistDialog) IsChecked(option *Option) (checked bool) {
+	return dialog.checked[option]
+}
+
+func (dialog *ChecklistDialog) SetChecked(option *Option, checked bool) {
+	if checked {
+		dialog.checked[option] = true
+	} else {
+		delete(dialog.checked, option)
+	}
+}
+
+// Function GetCheckedOptions returns the checked options, in the order they appear in the dialog.
+// Options that have been removed from the dialog are left out, even if they were checked.
+func (dialog *ChecklistDialog) GetCheckedOptions() (checked []*Option) {
+	checked = make([]*Option, 0, len(dialog.checked))
+	for _, option := range dialog.options {
+		if dialog.checked[option] {
+			checked = append(checked, option)
+		}
+	}
+	return checked
+}
+
+// Function SetCheckedOptions checks exactly the given options.
+func (dialog *ChecklistDialog) SetCheckedOptions(checked []*Option) {
+	dialog.checked = make(map[*Option]bool)
+	for _, option := range checked {
+		dialog.checked[option] = true
+	}
+}
+
+func (dialog *ChecklistDialog) GetCallback() (callback func([]*Option, interface{}) bool) {
+	return dialog.callback
+}
+
+func (dialog *ChecklistDialog) SetCallback(callback func([]*Option, interface{}) bool) {
+	dialog.callback = callback
+}
+
+func (dialog *ChecklistDialog) GetCallbackArg() (arg interface{}) {
+	return dialog.arg
+}
+
+func (dialog *ChecklistDialog) SetCallbackArg(arg interface{}) {
+	dialog.arg = arg
+}
+
+func (dialog *ChecklistDialog) HandleEvent(event termbox.Event) (handled bool, shouldClose bool) {
+	if event.Type != termbox.EventKey {
+		return dialog.SelectionDialog.HandleEvent(event)
+	}
+
+	filtering := dialog.filterMode != NoFilter
+
+	switch {
+	case event.Ch == 0 && event.Key == termbox.KeySpace:
+		if dialog.shown() > 0 {
+			option := dialog.GetSelectedOption()
+			dialog.SetChecked(option, !dialog.checked[option])
+		}
+		return true, false
+
+	case event.Ch == 0 && event.Key == termbox.KeyEnter:
+		shouldClose = dialog.callback == nil || dialog.callback(dialog.GetCheckedOptions(), dialog.arg)
+		if shouldClose {
+			dialog.result = Confirmed
+		}
+		return true, shouldClose
+
+	case !filtering && event.Mod&termbox.ModAlt == 0 && event.Ch == 'a':
+		for _, option := range dialog.options {
+			dialog.checked[option] = true
+		}
+		return true, false
+
+	case !filtering && event.Mod&termbox.ModAlt == 0 && event.Ch == 'i':
+		for _, option := range dialog.options {
+			dialog.SetChecked(option, !dialog.checked[option])
+		}
+		return true, false
+	}
+
+	return dialog.SelectionDialog.HandleEvent(event)
+}
@@ -0,0 +1,203 @@
+package termdialog
+
+import (
+	"github.com/nsf/termbox-go"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Type Completer represents a source of completions for an InputDialog. Complete is given the value
+// and the position of the cursor within it (counted in runes), and returns the candidates that the
+// text before the cursor could be completed to. Each candidate replaces all of the text before the
+// cursor.
+type Completer interface {
+	Complete(value string, cursor int) (candidates []string)
+}
+
+// Type CompleterFunc is an adapter that allows an ordinary function to be used as a Completer.
+type CompleterFunc func(value string, cursor int) (candidates []string)
+
+func (f CompleterFunc) Complete(value string, cursor int) (candidates []string) {
+	return f(value, cursor)
+}
+
+// Variable PathCompleter is a Completer for filesystem paths. Directories are completed with a
+// trailing separator, so that pressing Tab again continues into them. Hidden files are only offered
+// once a "." has been typed.
+var PathCompleter Completer = CompleterFunc(completePath)
+
+func completePath(value string, cursor int) (candidates []string) {
+	prefix := string([]rune(value)[:cursor])
+	dir, base := filepath.Split(prefix)
+
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	} else if strings.HasPrefix(readDir, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		readDir = filepath.Join(home, readDir[2:])
+	}
+
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+
+		candidate := dir + name
+		if info, err := os.Stat(filepath.Join(readDir, name)); err == nil && info.IsDir() {
+			candidate += string(filepath.Separator)
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates
+}
+
+// commonPrefix returns the longest prefix shared by all of strs that does not end part-way through
+// a grapheme cluster.
+func commonPrefix(strs []string) (prefix []rune) {
+	prefix = []rune(strs[0])
+	for _, str := range strs[1:] {
+		runes := []rune(str)
+		n := 0
+		for n < len(prefix) && n < len(runes) && prefix[n] == runes[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+
+	if len(prefix) < len([]rune(strs[0])) {
+		prefix = prefix[:prevBoundary(graphemeBoundaries([]rune(strs[0])), len(prefix)+1)]
+	}
+	return prefix
+}
+
+// maxCompletionsVisible is the number of candidates a completion popup shows before it scrolls.
+const maxCompletionsVisible = 8
+
+// completionPopup is the list of candidates that opens under an input dialog's field when Tab is
+// pressed twice.
+type completionPopup struct {
+	SelectionDialog
+	input *InputDialog
+}
+
+func newCompletionPopup(input *InputDialog, candidates []string) (popup *completionPopup) {
+	popup = &completionPopup{
+		input: input,
+	}
+
+	for _, candidate := range candidates {
+		popup.options = append(popup.options, &Option{candidate, popup.choose, nil})
+	}
+
+	popup.BaseDialog.metricsDirty = true
+	popup.BaseDialog.theme = input.theme
+	return popup
+}
+
+func (popup *completionPopup) choose(option *Option) (shouldClose bool) {
+	popup.input.applyCompletion([]rune(option.Text))
+	return true
+}
+
+// Function CalcMetrics places the popup directly under the field, or above it if there is more
+// room there.
+func (popup *completionPopup) CalcMetrics() {
+	windowWidth, windowHeight := CurrentBackend.Size()
+
+	maxWidth := 0
+	for _, option := range popup.options {
+		if stringWidth(option.Text) > maxWidth {
+			maxWidth = stringWidth(option.Text)
+		}
+	}
+
+	popup.width = 4 + maxWidth // 4 = "| " + " |"
+	popup.height = 2 + min(len(popup.options), maxCompletionsVisible)
+	popup.x = popup.input.fieldX() - 2
+	popup.y = popup.input.y + 5
+
+	fieldY := popup.input.y + 4
+	below := windowHeight - (fieldY + 1)
+	if popup.height > below && fieldY > below {
+		popup.height = min(popup.height, fieldY)
+		popup.y = fieldY - popup.height
+	} else {
+		popup.height = min(popup.height, below)
+	}
+
+	if popup.width > windowWidth {
+		popup.width = windowWidth
+	}
+	if popup.x+popup.width > windowWidth {
+		popup.x = windowWidth - popup.width
+	}
+	if popup.x < 0 {
+		popup.x = 0
+	}
+
+	popup.metricsDirty = false
+}
+
+func (popup *completionPopup) Open() {
+	if popup.metricsDirty {
+		popup.CalcMetrics()
+	}
+	if popup.input.tooSmall || popup.height < 3 {
+		return
+	}
+
+	theme := popup.theme
+	DrawBox(popup.x, popup.y, popup.width, popup.height, theme.Border)
+	Fill(popup.x+1, popup.y+1, popup.width-2, popup.height-2, ' ', theme.Dialog)
+
+	visible := min(popup.height-2, len(popup.options))
+	popup.scrollToSelection(visible)
+
+	for k := 0; k < visible; k++ {
+		i := popup.topIndex + k
+		y := popup.y + 1 + k
+
+		style := theme.InactiveItem
+		if i == popup.selectedIndex {
+			style = theme.ActiveItem
+		}
+
+		Fill(popup.x+1, y, popup.width-2, 1, ' ', style)
+		drawClippedString(popup.x+2, y, popup.options[i].Text, style, popup.x+2, y, popup.x+popup.width-2, y+1)
+	}
+}
+
+// Function HandleEvent lets the user pick a candidate with the arrow keys (or Tab) and Enter. Keys
+// that edit the value close the popup and are passed on to the input dialog.
+func (popup *completionPopup) HandleEvent(event termbox.Event) (handled bool, shouldClose bool) {
+	if event.Type == termbox.EventKey {
+		switch {
+		case event.Key == termbox.KeyTab:
+			event.Key = termbox.KeyArrowDown
+			if popup.selectedIndex == len(popup.options)-1 {
+				event.Key = termbox.KeyHome
+			}
+
+		case event.Ch != 0, event.Key == termbox.KeySpace, event.Key == termbox.KeyBackspace,
+			event.Key == termbox.KeyBackspace2, event.Key == termbox.KeyDelete,
+			event.Key == termbox.KeyArrowLeft, event.Key == termbox.KeyArrowRight:
+			popup.result = Cancelled
+			popup.input.HandleEvent(event)
+			return true, true
+		}
+	}
+
+	return popup.SelectionDialog.HandleEvent(event)
+}
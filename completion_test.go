@@ -0,0 +1,39 @@
+package termdialog
+
+import (
+	"context"
+	"github.com/nsf/termbox-go"
+	"testing"
+)
+
+func TestCompletionRespectsMaxLength(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []string
+		want       string
+	}{
+		{"too long", []string{"abcdefghij"}, "ab"},
+		{"one fits", []string{"abcd", "abcdefghij"}, "abcd"},
+		{"prefix of fitting", []string{"abcd", "abce", "abcdefghij"}, "abc"},
+	}
+
+	for _, test := range tests {
+		screen := NewVirtualScreen(80, 24)
+		CurrentBackend = screen
+		stack := NewDialogStack()
+		dialog := NewInputDialog("Title", "Value:", 20, "ab", nil, nil)
+		dialog.SetMaxLength(4)
+		candidates := test.candidates
+		dialog.SetCompleter(CompleterFunc(func(value string, cursor int) []string {
+			return candidates
+		}))
+		stack.Open(dialog)
+
+		screen.QueueKey(termbox.KeyTab)
+		stack.RunContext(context.Background())
+
+		if dialog.GetValue() != test.want {
+			t.Errorf("%s: value is %q after Tab, want %q", test.name, dialog.GetValue(), test.want)
+		}
+	}
+}
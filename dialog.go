@@ -1,6 +1,7 @@
 package termdialog
 
 import (
+	"fmt"
 	"github.com/nsf/termbox-go"
 )
 
@@ -22,6 +23,10 @@ type Dialog interface {
 	SetTheme(*Theme)
 	GetLastDialogStack() *DialogStack
 	SetLastDialogStack(*DialogStack)
+	GetResult() Result
+	SetResult(Result)
+	GetOnClose() func(Dialog, Result)
+	SetOnClose(func(Dialog, Result))
 	Close()
 
 	// Stuff implemented by subtypes
@@ -31,6 +36,20 @@ type Dialog interface {
 	HandleGlobalEvent(termbox.Event) (bool, bool)
 }
 
+// Type CursorDialog is implemented by dialogs that show the terminal cursor while they are the
+// active dialog on a stack.
+type CursorDialog interface {
+	CursorPosition() (x int, y int, visible bool)
+}
+
+// Type Paster is implemented by dialogs that take pasted text in one piece, rather than as the
+// series of keypresses it arrives as. HandlePaste is called with the text when a bracketed paste
+// ends while the dialog is active; its results mean the same as those of HandleEvent, and if it
+// returns false the keypresses are delivered after all.
+type Paster interface {
+	HandlePaste(text string) (handled bool, shouldClose bool)
+}
+
 type BaseDialog struct {
 	title           string
 	metricsDirty    bool
@@ -40,6 +59,11 @@ type BaseDialog struct {
 	y               int
 	theme           *Theme
 	lastDialogStack *DialogStack
+	result          Result
+	onClose         func(Dialog, Result)
+	minWidth        int  // the smallest size the dialog can still be drawn at
+	minHeight       int  //
+	tooSmall        bool // whether the screen is smaller than minWidth x minHeight
 }
 
 func (dialog *BaseDialog) GetTitle() (title string) {
@@ -109,6 +133,24 @@ func (dialog *BaseDialog) SetLastDialogStack(lastDialogStack *DialogStack) {
 	dialog.lastDialogStack = lastDialogStack
 }
 
+func (dialog *BaseDialog) GetResult() (result Result) {
+	return dialog.result
+}
+
+func (dialog *BaseDialog) SetResult(result Result) {
+	dialog.result = result
+}
+
+func (dialog *BaseDialog) GetOnClose() (onClose func(Dialog, Result)) {
+	return dialog.onClose
+}
+
+// Function SetOnClose sets a hook that is called whenever the dialog is removed from a dialog
+// stack, with the dialog itself and the result it was closed with.
+func (dialog *BaseDialog) SetOnClose(onClose func(Dialog, Result)) {
+	dialog.onClose = onClose
+}
+
 func (dialog *BaseDialog) Close() {
 	if dialog.metricsDirty {
 		dialog.CalcMetrics()
@@ -125,11 +167,82 @@ func (dialog *BaseDialog) HandleGlobalEvent(event termbox.Event) (handled bool,
 	return false, false
 }
 
-func BaseDialogOpen(dialog Dialog) {
+func (dialog *BaseDialog) baseDialog() (base *BaseDialog) {
+	return dialog
+}
+
+// baseOf returns the BaseDialog embedded in a dialog, or nil if it has none.
+func baseOf(dialog Dialog) (base *BaseDialog) {
+	d, ok := dialog.(interface{ baseDialog() *BaseDialog })
+	if !ok {
+		return nil
+	}
+	return d.baseDialog()
+}
+
+// fitToScreen shrinks the dialog to fit on the screen if necessary and centres it. Subtypes call
+// it at the end of CalcMetrics, passing the smallest size at which their content is still usable;
+// if the screen is smaller than that, the dialog is replaced by a message asking for a larger
+// terminal.
+func (dialog *BaseDialog) fitToScreen(minWidth int, minHeight int) {
+	windowWidth, windowHeight := CurrentBackend.Size()
+
+	dialog.minWidth = minWidth
+	dialog.minHeight = minHeight
+	dialog.tooSmall = windowWidth < minWidth || windowHeight < minHeight
+
+	if dialog.width > windowWidth {
+		dialog.width = windowWidth
+	}
+	if dialog.height > windowHeight {
+		dialog.height = windowHeight
+	}
+
+	dialog.x = (windowWidth / 2) - (dialog.width / 2)
+	dialog.y = (windowHeight / 2) - (dialog.height / 2)
+}
+
+// drawContent draws text inside the dialog, truncating anything that would spill over the padding
+// inside the border.
+func (dialog *BaseDialog) drawContent(x int, y int, str string, style Style) {
+	drawClippedString(x, y, str, style, dialog.x+3, dialog.y+1, dialog.x+dialog.width-3, dialog.y+dialog.height-2)
+}
+
+// drawTooSmall covers the screen with a message saying how large the terminal needs to be. The
+// message is shortened to fit the screen, and left out altogether rather than showing only part of
+// the size.
+func (dialog *BaseDialog) drawTooSmall() {
+	windowWidth, windowHeight := CurrentBackend.Size()
+	size := fmt.Sprintf("%dx%d", dialog.minWidth, dialog.minHeight)
+
+	message := ""
+	for _, m := range []string{"terminal too small (need " + size + ")", "need " + size, size} {
+		if len(m) <= windowWidth {
+			message = m
+			break
+		}
+	}
+
+	x := (windowWidth - len(message)) / 2
+
+	Fill(0, 0, windowWidth, windowHeight, ' ', dialog.theme.Screen)
+	DrawString(x, windowHeight/2, message, dialog.theme.InactiveItem)
+}
+
+// Function BaseDialogOpen draws the parts common to all dialogs: the shadow, border, background and
+// title. It returns false if the screen is too small for the dialog, in which case a message saying
+// so has been drawn instead and the caller should not draw anything else.
+func BaseDialogOpen(dialog Dialog) (ok bool) {
 	if dialog.GetMetricsDirty() {
 		dialog.CalcMetrics()
 	}
 
+	base := baseOf(dialog)
+	if base != nil && base.tooSmall {
+		base.drawTooSmall()
+		return false
+	}
+
 	title := dialog.GetTitle()
 	x := dialog.GetX()
 	y := dialog.GetY()
@@ -144,7 +257,13 @@ func BaseDialogOpen(dialog Dialog) {
 	DrawBox(x, y, width, height, theme.Border)
 	Fill(x+1, y+1, width-2, height-2, ' ', theme.Dialog)
 
-	DrawString(x+3, y+2, title, theme.Title)
+	if base != nil {
+		base.drawContent(x+3, y+2, title, theme.Title)
+	} else {
+		DrawString(x+3, y+2, title, theme.Title)
+	}
+
+	return true
 }
 
 func BaseDialogHandleEvent(dialog Dialog, event termbox.Event) (handled bool, shouldClose bool) {
@@ -153,6 +272,7 @@ func BaseDialogHandleEvent(dialog Dialog, event termbox.Event) (handled bool, sh
 		if event.Ch == 0 {
 			switch event.Key {
 			case termbox.KeyEsc:
+				dialog.SetResult(Cancelled)
 				return true, true
 
 			case termbox.KeyF1:
@@ -0,0 +1,32 @@
+package termdialog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTooSmallMessageFitsScreen(t *testing.T) {
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{40, "terminal too small (need 10x7)"},
+		{20, "need 10x7"},
+		{8, "10x7"},
+		{3, ""},
+	}
+
+	for _, test := range tests {
+		screen := NewVirtualScreen(test.width, 5)
+		CurrentBackend = screen
+
+		stack := NewDialogStack()
+		stack.Open(NewMessageDialog("Title", "Hello"))
+		stack.Draw()
+
+		got := strings.TrimSpace(screen.Line(2))
+		if got != test.want {
+			t.Errorf("at width %d, message is %q, want %q", test.width, got, test.want)
+		}
+	}
+}
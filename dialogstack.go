@@ -1,11 +1,16 @@
 package termdialog
 
 import (
+	"context"
 	"github.com/nsf/termbox-go"
+	"sync"
 )
 
 type DialogStack struct {
 	dialogs []Dialog
+	ctx     context.Context // the context passed to RunContext, if it is running
+	posted  []func()        // functions queued by Post
+	mutex   sync.Mutex      // protects posted
 }
 
 func NewDialogStack() (dialogStack *DialogStack) {
@@ -15,8 +20,11 @@ func NewDialogStack() (dialogStack *DialogStack) {
 }
 
 func (dialogStack *DialogStack) Open(dialog Dialog) {
+	// The screen may have been resized since the dialog was last shown.
+	dialog.SetMetricsDirty(true)
 	dialog.Open()
 	dialog.SetLastDialogStack(dialogStack)
+	dialog.SetResult(Pending)
 	dialogStack.dialogs = append(dialogStack.dialogs, dialog)
 	//return dialog
 }
@@ -27,6 +35,8 @@ func (dialogStack *DialogStack) Close(dialog Dialog) {
 	for i, d := range dialogStack.dialogs {
 		if d == dialog {
 			dialogStack.dialogs = append(dialogStack.dialogs[:i], dialogStack.dialogs[i+1:]...)
+			closed(dialog, Aborted)
+			break
 		}
 	}
 }
@@ -35,39 +45,289 @@ func (dialogStack *DialogStack) CloseTop() {
 	dialog := dialogStack.dialogs[len(dialogStack.dialogs)-1]
 	dialog.Close()
 	dialogStack.dialogs = dialogStack.dialogs[:len(dialogStack.dialogs)-1]
+	closed(dialog, Aborted)
 	//return dialog
 }
 
-func (dialogStack *DialogStack) Run() {
-	_, windowHeight := termbox.Size()
+// closeHandler is implemented by dialogs that need to tidy up whenever they are removed from a
+// stack, however that happens.
+type closeHandler interface {
+	handleClose()
+}
+
+// closed records the result of a dialog that has just been removed from a stack and calls its
+// OnClose hook. If the dialog has not recorded a result of its own (i.e. it was closed by the
+// application rather than the user), fallback is recorded instead.
+func closed(dialog Dialog, fallback Result) {
+	if dialog.GetResult() == Pending {
+		dialog.SetResult(fallback)
+	}
+
+	handler, ok := dialog.(closeHandler)
+	if ok {
+		handler.handleClose()
+	}
+
+	onClose := dialog.GetOnClose()
+	if onClose != nil {
+		onClose(dialog, dialog.GetResult())
+	}
+}
+
+// Function Draw redraws the background and every open dialog, bottom to top, and flushes the
+// result to the backend.
+func (dialogStack *DialogStack) Draw() {
+	_, windowHeight := CurrentBackend.Size()
 
 	//Fill(0, 0, windowWidth, windowHeight, ' ', DefaultTheme.Screen.FG, DefaultTheme.Screen.BG)
-	termbox.Clear(DefaultTheme.Screen.FG, DefaultTheme.Screen.BG)
+	CurrentBackend.Clear(DefaultTheme.Screen.FG, DefaultTheme.Screen.BG)
 	DrawString(0, windowHeight-1, "F1: TermDialog help", DefaultTheme.InactiveItem)
 
-	for len(dialogStack.dialogs) > 0 {
-		for _, dialog := range dialogStack.dialogs {
-			dialog.Open()
+	for _, dialog := range dialogStack.dialogs {
+		dialog.Open()
+	}
+
+	cursorX, cursorY := -1, -1
+	if len(dialogStack.dialogs) > 0 {
+		cursorDialog, ok := dialogStack.dialogs[len(dialogStack.dialogs)-1].(CursorDialog)
+		if ok {
+			x, y, visible := cursorDialog.CursorPosition()
+			if visible {
+				cursorX, cursorY = x, y
+			}
+		}
+	}
+
+	CurrentBackend.SetCursor(cursorX, cursorY)
+	CurrentBackend.Flush()
+}
+
+// Function Relayout marks the metrics of every open dialog as dirty, so that they are resized and
+// recentred when the stack is next drawn. It is called automatically when the terminal is resized.
+func (dialogStack *DialogStack) Relayout() {
+	for _, dialog := range dialogStack.dialogs {
+		dialog.SetMetricsDirty(true)
+	}
+}
+
+// Function Run runs the event loop until there are no dialogs left to show, Stop is called, or
+// the backend stops delivering events.
+func (dialogStack *DialogStack) Run() {
+	dialogStack.RunContext(context.Background())
+}
+
+// Function RunContext is like Run, but also returns when ctx is cancelled, interrupting the
+// backend if it is waiting for input. In that case every open dialog is closed (with the result
+// TimedOut if the context's deadline was exceeded, and Aborted otherwise) and ctx.Err() is
+// returned. If the dialogs have all been closed by the time the context is cancelled, nil is
+// returned as usual. If the backend stops delivering events, the error it reported is returned.
+func (dialogStack *DialogStack) RunContext(ctx context.Context) (err error) {
+	parent := dialogStack.ctx
+	dialogStack.ctx = ctx
+	defer func() { dialogStack.ctx = parent }()
+
+	// The watcher must not interrupt the backend once the loop has returned, or the interrupt
+	// would be left for whoever polls it next.
+	var mutex sync.Mutex
+	running := true
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			mutex.Lock()
+			if running {
+				CurrentBackend.Interrupt()
+			}
+			mutex.Unlock()
+		case <-done:
+		}
+	}()
+
+	err = dialogStack.runUntil(func() bool { return false })
+
+	mutex.Lock()
+	running = false
+	mutex.Unlock()
+	close(done)
+	<-stopped
+
+	return err
+}
+
+// runUntil runs the event loop until done returns true or there are no dialogs left to show. It
+// returns an error if it gave up early, because the context passed to RunContext was cancelled
+// or because the backend stopped delivering events.
+func (dialogStack *DialogStack) runUntil(done func() bool) (err error) {
+	ctx := dialogStack.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for {
+		dialogStack.runPosted()
+		if len(dialogStack.dialogs) == 0 || done() {
+			return nil
 		}
-		termbox.Flush()
-
-		activeDialog := dialogStack.dialogs[len(dialogStack.dialogs)-1]
-		event := termbox.PollEvent()
-
-		handled, shouldClose := activeDialog.HandleEvent(event)
-		if !handled {
-			for i := len(dialogStack.dialogs) - 1; i >= 0; i-- {
-				handled, shouldClose = dialogStack.dialogs[i].HandleGlobalEvent(event)
-				if handled {
-					break
-				}
+
+		if ctx.Err() != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				dialogStack.stop(TimedOut)
+			} else {
+				dialogStack.stop(Aborted)
 			}
+			return ctx.Err()
 		}
 
+		dialogStack.Draw()
+
+		event := CurrentBackend.PollEvent()
+		switch event.Type {
+		case termbox.EventError:
+			// The backend can no longer deliver input (e.g. the terminal was closed, or a
+			// VirtualScreen ran out of scripted events), so there is nothing left to wait for.
+			return event.Err
+
+		case termbox.EventResize:
+			dialogStack.Relayout()
+
+		case termbox.EventInterrupt:
+			// Nothing to do; posted functions and the context are checked at the top of the
+			// loop, and the screen is redrawn.
+
+		case EventPasteStart:
+			err = dialogStack.paste()
+			if err != nil {
+				return err
+			}
+
+		default:
+			dialogStack.handleEvent(event)
+		}
+	}
+}
+
+// paste reads the keypresses that make up a bracketed paste, up to the EventPasteEnd, and delivers
+// them to the active dialog all at once: as text, if it is a Paster, or otherwise one by one.
+// Either way, the screen is only redrawn once the whole paste has been handled.
+func (dialogStack *DialogStack) paste() (err error) {
+	var events []termbox.Event
+
+collect:
+	for {
+		event := CurrentBackend.PollEvent()
+		switch event.Type {
+		case termbox.EventError:
+			return event.Err
+
+		case termbox.EventResize:
+			dialogStack.Relayout()
+
+		case termbox.EventKey:
+			events = append(events, event)
+
+		case EventPasteEnd:
+			break collect
+		}
+	}
+
+	if len(dialogStack.dialogs) == 0 {
+		return nil
+	}
+
+	activeDialog := dialogStack.dialogs[len(dialogStack.dialogs)-1]
+	if paster, ok := activeDialog.(Paster); ok {
+		handled, shouldClose := paster.HandlePaste(pasteText(events))
 		if shouldClose {
 			dialogStack.Close(activeDialog)
 		}
+		if handled {
+			return nil
+		}
+	}
+
+	for _, event := range events {
+		if len(dialogStack.dialogs) == 0 {
+			break
+		}
+		dialogStack.handleEvent(event)
 	}
+	return nil
+}
+
+// pasteText converts the keypresses of a paste back into the text that was pasted.
+func pasteText(events []termbox.Event) (text string) {
+	runes := make([]rune, 0, len(events))
+	for _, event := range events {
+		switch {
+		case event.Ch != 0:
+			runes = append(runes, event.Ch)
+		case event.Key == termbox.KeySpace:
+			runes = append(runes, ' ')
+		case event.Key == termbox.KeyTab:
+			runes = append(runes, '\t')
+		case event.Key == termbox.KeyEnter, event.Key == termbox.KeyCtrlJ:
+			runes = append(runes, '\n')
+		}
+	}
+	return string(runes)
+}
+
+// Function Post queues f to be called on the goroutine running the event loop, after which the
+// screen is redrawn. It may be called from any goroutine, and is the only safe way for other
+// goroutines to modify open dialogs or to call Open, Close or Stop while the stack is running.
+// Functions are called in the order they were posted; if the stack is not running, they are
+// called when it is next run.
+func (dialogStack *DialogStack) Post(f func()) {
+	dialogStack.mutex.Lock()
+	dialogStack.posted = append(dialogStack.posted, f)
+	dialogStack.mutex.Unlock()
+
+	CurrentBackend.Interrupt()
+}
+
+// runPosted calls all functions queued by Post.
+func (dialogStack *DialogStack) runPosted() {
+	dialogStack.mutex.Lock()
+	posted := dialogStack.posted
+	dialogStack.posted = nil
+	dialogStack.mutex.Unlock()
+
+	for _, f := range posted {
+		f()
+	}
+}
+
+// handleEvent delivers an event to the active dialog, falling back to the global event handlers
+// of every open dialog from the top down.
+func (dialogStack *DialogStack) handleEvent(event termbox.Event) {
+	activeDialog := dialogStack.dialogs[len(dialogStack.dialogs)-1]
+
+	handled, shouldClose := activeDialog.HandleEvent(event)
+	if !handled {
+		for i := len(dialogStack.dialogs) - 1; i >= 0; i-- {
+			handled, shouldClose = dialogStack.dialogs[i].HandleGlobalEvent(event)
+			if handled {
+				break
+			}
+		}
+	}
+
+	if shouldClose {
+		dialogStack.Close(activeDialog)
+	}
+}
+
+// Function IsOpen returns whether the dialog is currently open on this stack.
+func (dialogStack *DialogStack) IsOpen(dialog Dialog) (isOpen bool) {
+	for _, d := range dialogStack.dialogs {
+		if d == dialog {
+			return true
+		}
+	}
+	return false
 }
 
 func (dialogStack *DialogStack) Stop() {
@@ -75,5 +335,16 @@ func (dialogStack *DialogStack) Stop() {
 	// Therefore, on the next iteration of Run, the test "len(dialogStack.dialogs) > 0"
 	// will fail and the loop will exit.
 
+	dialogStack.stop(Aborted)
+}
+
+// stop removes all dialogs, closing any that have not recorded a result of their own with the
+// given one.
+func (dialogStack *DialogStack) stop(result Result) {
+	dialogs := dialogStack.dialogs
 	dialogStack.dialogs = nil
+
+	for i := len(dialogs) - 1; i >= 0; i-- {
+		closed(dialogs[i], result)
+	}
 }
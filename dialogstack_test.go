@@ -0,0 +1,138 @@
+package termdialog
+
+import (
+	"context"
+	"github.com/nsf/termbox-go"
+	"strings"
+	"testing"
+)
+
+func TestInputDialogCallbackReceivesTypedValue(t *testing.T) {
+	screen := NewVirtualScreen(80, 24)
+	CurrentBackend = screen
+
+	var got string
+	calls := 0
+	dialog := NewInputDialog("Name", "Enter a name:", 20, "", func(value string, arg interface{}) bool {
+		got = value
+		calls++
+		return true
+	}, nil)
+
+	stack := NewDialogStack()
+	stack.Open(dialog)
+	screen.QueueString("abc")
+	screen.QueueKey(termbox.KeyEnter)
+
+	err := stack.RunContext(context.Background())
+	if err != nil {
+		t.Fatalf("RunContext returned %v, want nil", err)
+	}
+	if calls != 1 || got != "abc" {
+		t.Errorf("callback called %d times with %q, want once with %q", calls, got, "abc")
+	}
+	if stack.IsOpen(dialog) {
+		t.Error("dialog still open after Enter")
+	}
+	if dialog.GetResult() != Confirmed {
+		t.Errorf("result is %v, want %v", dialog.GetResult(), Confirmed)
+	}
+}
+
+func TestRunEndsWithErrNoMoreEvents(t *testing.T) {
+	screen := NewVirtualScreen(80, 24)
+	CurrentBackend = screen
+
+	dialog := NewMessageDialog("Title", "Hello")
+	stack := NewDialogStack()
+	stack.Open(dialog)
+	screen.QueueString("x")
+
+	err := stack.RunContext(context.Background())
+	if err != ErrNoMoreEvents {
+		t.Fatalf("RunContext returned %v, want ErrNoMoreEvents", err)
+	}
+	if !stack.IsOpen(dialog) {
+		t.Error("dialog was closed when the events ran out")
+	}
+	if dialog.GetResult() != Pending {
+		t.Errorf("result is %v, want %v", dialog.GetResult(), Pending)
+	}
+}
+
+func TestQueueResizeRelaysOutDialogs(t *testing.T) {
+	screen := NewVirtualScreen(80, 24)
+	CurrentBackend = screen
+
+	dialog := NewMessageDialog("Title", "Hello")
+	stack := NewDialogStack()
+	stack.Open(dialog)
+	stack.Draw()
+	oldX, oldY := dialog.GetX(), dialog.GetY()
+
+	screen.QueueResize(40, 12)
+	stack.RunContext(context.Background())
+
+	width, height := screen.Size()
+	if width != 40 || height != 12 {
+		t.Fatalf("screen is %dx%d after resize, want 40x12", width, height)
+	}
+
+	wantX := 40/2 - dialog.GetWidth()/2
+	wantY := 12/2 - dialog.GetHeight()/2
+	if dialog.GetX() != wantX || dialog.GetY() != wantY {
+		t.Errorf("dialog at (%d, %d) after resize, want (%d, %d) (was at (%d, %d))", dialog.GetX(), dialog.GetY(), wantX, wantY, oldX, oldY)
+	}
+	if !strings.Contains(screen.Line(dialog.GetY()+2), "Title") {
+		t.Errorf("title not drawn at the new position:\n%s", screen.String())
+	}
+}
+
+func TestRunContextReturnsNilIfDialogsClosedBeforeCancel(t *testing.T) {
+	screen := NewVirtualScreen(80, 24)
+	CurrentBackend = screen
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dialog := NewInputDialog("Name", "Enter a name:", 20, "", func(value string, arg interface{}) bool {
+		return true
+	}, nil)
+
+	stack := NewDialogStack()
+	stack.Open(dialog)
+	screen.QueueKey(termbox.KeyEnter)
+
+	err := stack.RunContext(ctx)
+	cancel()
+	if err != nil {
+		t.Fatalf("RunContext returned %v, want nil", err)
+	}
+	if dialog.GetResult() != Confirmed {
+		t.Errorf("result is %v, want %v", dialog.GetResult(), Confirmed)
+	}
+
+	// The watcher has stopped, so cancelling the context must not queue an interrupt.
+	event := screen.PollEvent()
+	if event.Type != termbox.EventError || event.Err != ErrNoMoreEvents {
+		t.Errorf("stray event %+v queued after RunContext returned", event)
+	}
+}
+
+func TestRunContextReturnsErrWhenCancelled(t *testing.T) {
+	screen := NewVirtualScreen(80, 24)
+	CurrentBackend = screen
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dialog := NewInputDialog("Name", "Enter a name:", 20, "", nil, nil)
+
+	stack := NewDialogStack()
+	stack.Open(dialog)
+	stack.Post(cancel)
+
+	err := stack.RunContext(ctx)
+	if err != context.Canceled {
+		t.Fatalf("RunContext returned %v, want context.Canceled", err)
+	}
+	if stack.IsOpen(dialog) || dialog.GetResult() != Aborted {
+		t.Errorf("dialog open %v with result %v, want closed with %v", stack.IsOpen(dialog), dialog.GetResult(), Aborted)
+	}
+}
@@ -0,0 +1,197 @@
+package termdialog
+
+import (
+	"unicode"
+)
+
+// Scores used to rank fuzzy matches, modelled on fzf's. Each matched character scores points, with
+// a bonus if it starts a word or immediately follows the previous match, and each gap between
+// matched characters costs points, so that "kprod" ranks "k8s-prod" above "kube-system-prod-old".
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+
+	bonusBoundary            = 8 // the character starts a word, e.g. after a space, "-", "_", "/" or "."
+	bonusCamelCase           = 7 // the character is upper case after a lower case letter, or a digit after a letter
+	bonusConsecutive         = 4 // the character immediately follows the previous match
+	bonusFirstCharMultiplier = 2 // the bonus for the first character of the query counts double
+)
+
+// charClass classifies characters for the purpose of finding the starts of words.
+type charClass int
+
+const (
+	classNonWord charClass = iota
+	classLower
+	classUpper
+	classLetter // a letter with no case
+	classDigit
+)
+
+func classOf(c rune) (class charClass) {
+	switch {
+	case unicode.IsLower(c):
+		return classLower
+	case unicode.IsUpper(c):
+		return classUpper
+	case unicode.IsLetter(c):
+		return classLetter
+	case unicode.IsDigit(c):
+		return classDigit
+	}
+	return classNonWord
+}
+
+// matchBonus returns the bonus for matching text[j].
+func matchBonus(text []rune, j int) (bonus int) {
+	class := classOf(text[j])
+	if class == classNonWord {
+		return 0
+	}
+
+	prev := classNonWord
+	if j > 0 {
+		prev = classOf(text[j-1])
+	}
+
+	switch {
+	case prev == classNonWord:
+		return bonusBoundary
+	case prev == classLower && class == classUpper, prev != classDigit && class == classDigit:
+		return bonusCamelCase
+	}
+	return 0
+}
+
+// lowerRunes returns a copy of runes with every letter in lower case.
+func lowerRunes(runes []rune) (lower []rune) {
+	lower = make([]rune, len(runes))
+	for i, c := range runes {
+		lower[i] = unicode.ToLower(c)
+	}
+	return lower
+}
+
+// substringMatch finds the first place that query (in lower case) appears in text, ignoring case,
+// and returns the offsets of the runes of text that it covers.
+func substringMatch(text []rune, query []rune) (positions []int, ok bool) {
+	lower := lowerRunes(text)
+
+outer:
+	for start := 0; start+len(query) <= len(lower); start++ {
+		for i, c := range query {
+			if lower[start+i] != c {
+				continue outer
+			}
+		}
+
+		positions = make([]int, len(query))
+		for i := range positions {
+			positions[i] = start + i
+		}
+		return positions, true
+	}
+
+	return nil, false
+}
+
+// fuzzyMatch finds the best way of matching the characters of query (in lower case) in order
+// against text, ignoring case, and returns its score and the offsets of the runes of text that
+// were matched.
+func fuzzyMatch(text []rune, query []rune) (score int, positions []int, ok bool) {
+	if len(query) == 0 {
+		return 0, nil, true
+	}
+
+	lower := lowerRunes(text)
+
+	// Most options do not match at all, which is quick to check before scoring.
+	rest := query
+	for _, c := range lower {
+		if len(rest) > 0 && c == rest[0] {
+			rest = rest[1:]
+		}
+	}
+	if len(rest) > 0 {
+		return 0, nil, false
+	}
+
+	n := len(text)
+	bonuses := make([]int, n)
+	for j := range text {
+		bonuses[j] = matchBonus(text, j)
+	}
+
+	// scores[i*n+j] is the best score for matching query[:i+1] with query[i] matched at text[j],
+	// or -1 if that is impossible; from[i*n+j] is where query[i-1] was matched in that case, and
+	// runBonus[i*n+j] is the bonus of the first character in the run of consecutive matches
+	// ending there.
+	scores := make([]int, len(query)*n)
+	from := make([]int, len(query)*n)
+	runBonus := make([]int, len(query)*n)
+	for i := range scores {
+		scores[i] = -1
+	}
+
+	for j, c := range lower {
+		if c == query[0] {
+			scores[j] = scoreMatch + bonuses[j]*bonusFirstCharMultiplier
+			runBonus[j] = bonuses[j]
+		}
+	}
+
+	for i := 1; i < len(query); i++ {
+		for j := i; j < n; j++ {
+			if lower[j] != query[i] {
+				continue
+			}
+
+			cell := i*n + j
+			for k := i - 1; k < j; k++ {
+				prev := (i-1)*n + k
+				if scores[prev] < 0 {
+					continue
+				}
+
+				s := scores[prev] + scoreMatch
+				run := bonuses[j]
+				if k == j-1 {
+					s += max(max(bonuses[j], runBonus[prev]), bonusConsecutive)
+					run = max(runBonus[prev], bonuses[j])
+				} else {
+					s += bonuses[j] + scoreGapStart + (j-k-2)*scoreGapExtension
+				}
+
+				// Scores can drop below zero with enough gaps, so keep them positive to tell
+				// them apart from impossible matches.
+				if s < 1 {
+					s = 1
+				}
+
+				if s > scores[cell] {
+					scores[cell] = s
+					from[cell] = k
+					runBonus[cell] = run
+				}
+			}
+		}
+	}
+
+	last := -1
+	for j := 0; j < n; j++ {
+		cell := (len(query)-1)*n + j
+		if scores[cell] > 0 && (last < 0 || scores[cell] > score) {
+			score = scores[cell]
+			last = j
+		}
+	}
+
+	positions = make([]int, len(query))
+	for i := len(query) - 1; i >= 0; i-- {
+		positions[i] = last
+		last = from[i*n+last]
+	}
+
+	return score, positions, true
+}
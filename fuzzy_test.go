@@ -0,0 +1,132 @@
+package termdialog
+
+import (
+	"github.com/nsf/termbox-go"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFuzzyMatchPositions(t *testing.T) {
+	tests := []struct {
+		text      string
+		query     string
+		positions []int
+	}{
+		{"k8s-prod", "kprod", []int{0, 4, 5, 6, 7}},
+		{"kube-system-prod-old", "kprod", []int{0, 12, 13, 14, 15}},
+		{"K8S-PROD", "kprod", []int{0, 4, 5, 6, 7}},
+		{"FooBar", "fb", []int{0, 3}},
+		{"my_file.go", "mfg", []int{0, 3, 8}},
+		{"xaxbab", "ab", []int{4, 5}}, // consecutive matches beat an earlier start with a gap
+	}
+
+	for _, test := range tests {
+		_, positions, ok := fuzzyMatch([]rune(test.text), []rune(test.query))
+		if !ok || !reflect.DeepEqual(positions, test.positions) {
+			t.Errorf("fuzzyMatch(%q, %q) matched %v (ok %v), want %v", test.text, test.query, positions, ok, test.positions)
+		}
+	}
+}
+
+func TestFuzzyMatchRejects(t *testing.T) {
+	tests := []struct {
+		text  string
+		query string
+	}{
+		{"k8s-prod", "kprd8"},
+		{"abc", "abcd"},
+		{"", "a"},
+	}
+
+	for _, test := range tests {
+		_, _, ok := fuzzyMatch([]rune(test.text), []rune(test.query))
+		if ok {
+			t.Errorf("fuzzyMatch(%q, %q) matched, want no match", test.text, test.query)
+		}
+	}
+}
+
+// filtered returns the text of the options shown by dialog, in order.
+func filtered(dialog *SelectionDialog) (texts []string) {
+	for pos := 0; pos < dialog.shown(); pos++ {
+		texts = append(texts, dialog.options[dialog.optionAt(pos)].Text)
+	}
+	return texts
+}
+
+func TestFuzzyFilterRanking(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []string
+		query   string
+		want    []string
+	}{
+		{"gaps", []string{"kube-system-prod-old", "k8s-prod"}, "kprod", []string{"k8s-prod", "kube-system-prod-old"}},
+		{"tie broken on length", []string{"abc-def", "abc"}, "abc", []string{"abc", "abc-def"}},
+		{"word starts", []string{"xfxb", "foo-bar"}, "fb", []string{"foo-bar", "xfxb"}},
+		{"ignores case of query", []string{"alpha", "K8S-Prod"}, "KPROD", []string{"K8S-Prod"}},
+	}
+
+	for _, test := range tests {
+		CurrentBackend = NewVirtualScreen(80, 24)
+		options := make([]*Option, len(test.options))
+		for i, text := range test.options {
+			options[i] = &Option{Text: text}
+		}
+
+		dialog := NewSelectionDialog("Title", options)
+		dialog.SetFilterMode(FuzzyFilter)
+		dialog.SetFilter(test.query)
+
+		got := filtered(dialog)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: %q shows %q, want %q", test.name, test.query, got, test.want)
+		}
+		if dialog.GetSelectedOption().Text != test.want[0] {
+			t.Errorf("%s: %q selected, want the best match %q", test.name, dialog.GetSelectedOption().Text, test.want[0])
+		}
+	}
+}
+
+func TestFuzzyFilterDrawsHighlights(t *testing.T) {
+	screen := NewVirtualScreen(80, 24)
+	CurrentBackend = screen
+
+	dialog := NewSelectionDialog("Title", []*Option{
+		{Text: "kube-system-prod-old"},
+		{Text: "k8s-prod"},
+	})
+	dialog.SetTheme(PlainTheme)
+	dialog.SetFilterMode(FuzzyFilter)
+
+	stack := NewDialogStack()
+	stack.Open(dialog)
+	dialog.SetFilter("kprod")
+	stack.Draw()
+
+	want := map[string][]int{
+		"k8s-prod":             {0, 4, 5, 6, 7},
+		"kube-system-prod-old": {0, 12, 13, 14, 15},
+	}
+	for pos, text := range filtered(dialog) {
+		y := dialog.GetY() + 4 + pos
+		line := screen.Line(y)
+		index := strings.Index(line, text)
+		if index < 0 {
+			t.Fatalf("%q not drawn on row %d:\n%s", text, y, screen.String())
+		}
+		x := utf8.RuneCountInString(line[:index])
+
+		var bold []int
+		for i := range text {
+			if screen.Cell(x+i, y).Fg&termbox.AttrBold != 0 {
+				bold = append(bold, i)
+			}
+		}
+		if !reflect.DeepEqual(bold, want[text]) {
+			t.Errorf("%q drawn with characters %v highlighted, want %v", text, bold, want[text])
+		}
+	}
+}
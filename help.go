@@ -7,7 +7,11 @@ var (
 	HelpGeneralDialog   *MessageDialog
 	HelpMessageDialog   *MessageDialog
 	HelpSelectionDialog *MessageDialog
+	HelpChecklistDialog *MessageDialog
+	HelpRadioListDialog *MessageDialog
 	HelpInputDialog     *MessageDialog
+	HelpInputKeysDialog *MessageDialog
+	HelpTextAreaDialog  *MessageDialog
 )
 
 func OpenDialogCallback(option *Option) (shouldClose bool) {
@@ -26,13 +30,21 @@ func init() {
 
 	HelpGeneralDialog = NewMessageDialog("General help", "* Any dialog can be closed by pressing the escape key.")
 	HelpMessageDialog = NewMessageDialog("Message dialogs", "* Message dialogs display a simple text message.\r\n* Pressing <Enter> or <Space> will close the dialog.")
-	HelpSelectionDialog = NewMessageDialog("Selection dialogs", "* Selection dialogs offer a choice of options for the user to select.\r\n* Use the up and down arrow keys to select an option.\r\n* Press the <Enter> or <Space> key to choose the selected option.\r\n* You can also use the <Home> and <End> keys to navigate to the start and end of the list respectively.")
-	HelpInputDialog = NewMessageDialog("Input dialogs", "* Input dialogs allow the user to enter a line of text.\r\n* The <Backspace> key can be used as one would expect.\r\n* Pressing <Enter> will return the entered text to the application and close the dialog.")
+	HelpSelectionDialog = NewMessageDialog("Selection dialogs", "* Selection dialogs offer a choice of options for the user to select.\r\n* Use the up and down arrow keys to select an option.\r\n* Press the <Enter> or <Space> key to choose the selected option.\r\n* You can also use the <Home> and <End> keys to navigate to the start and end of the list respectively.\r\n* In some lists, typing narrows down the options shown.\r\n* Matching characters are highlighted, and the best matches come first.\r\n* <Backspace> deletes a typed character; <Esc> clears the filter.")
+	HelpChecklistDialog = NewMessageDialog("Checklist dialogs", "* Checklist dialogs allow the user to pick any number of options.\r\n* Use the up and down arrow keys to select an option, and press <Space> to check or uncheck it.\r\n* Press <a> to check every option, or <i> to invert which options are checked.\r\n* Pressing <Enter> will return the checked options to the application and close the dialog.")
+	HelpRadioListDialog = NewMessageDialog("Radio list dialogs", "* Radio list dialogs allow the user to pick one option, which is marked with (*).\r\n* Use the up and down arrow keys to select an option, and press <Space> to mark it.\r\n* Pressing <Enter> will return the marked option to the application and close the dialog.")
+	HelpInputDialog = NewMessageDialog("Input dialogs", "* Input dialogs allow the user to enter a line of text.\r\n* <Left> and <Right> move the cursor; hold <Ctrl> to move by word.\r\n* <Home> and <End> go to the start and end of the text.\r\n* <Backspace> and <Delete> delete a character.\r\n* Some fields only accept a value of a particular shape, such as a date; the separators are filled in for you.\r\n* If completion is available, <Tab> completes the text before the cursor; press it twice to list the choices.\r\n* If the dialog remembers earlier entries, <Up> and <Down> recall them and <Ctrl+R> searches them; <Esc> cancels a search.\r\n* In password fields, <Ctrl+R> may show or hide the entered text.\r\n* <Enter> returns the text to the application and closes the dialog, unless an error under the field says why the text is not accepted.\r\n* See \"Input editing keys\" for more keys.")
+	HelpInputKeysDialog = NewMessageDialog("Input editing keys", "Input dialogs also accept Emacs-style editing keys:\r\n* <Ctrl+A>, <Ctrl+E>: go to the start or end.\r\n* <Ctrl+B>, <Ctrl+F>: move back or forward a character.\r\n* <Alt+B>, <Alt+F>: move back or forward a word.\r\n* <Ctrl+D>: delete the character under the cursor.\r\n* <Ctrl+K>: cut to the end.\r\n* <Ctrl+U>: cut to the start.\r\n* <Ctrl+W>, <Alt+Backspace>: cut the previous word.\r\n* <Alt+D>: cut the next word.\r\n* <Ctrl+Y>: paste the text cut last.\r\n* <Alt+Y>: straight after <Ctrl+Y>, paste earlier cuts instead.\r\n* <Ctrl+_>: undo.")
+	HelpTextAreaDialog = NewMessageDialog("Text area dialogs", "* Text area dialogs allow the user to enter several lines of text.\r\n* Pressing <Enter> starts a new line; the key shown under the text returns the text to the application and closes the dialog.\r\n* The arrow keys move the cursor, and <Page Up> and <Page Down> move it a screenful at a time.\r\n* <Home> and <End> go to the start and end of the line; hold <Ctrl> to go to the start and end of the text.")
 
 	HelpDialog.AddOption(&Option{"General", OpenDialogCallback, HelpGeneralDialog})
 	HelpDialog.AddOption(&Option{"Message dialogs", OpenDialogCallback, HelpMessageDialog})
 	HelpDialog.AddOption(&Option{"Selection dialogs", OpenDialogCallback, HelpSelectionDialog})
+	HelpDialog.AddOption(&Option{"Checklist dialogs", OpenDialogCallback, HelpChecklistDialog})
+	HelpDialog.AddOption(&Option{"Radio list dialogs", OpenDialogCallback, HelpRadioListDialog})
 	HelpDialog.AddOption(&Option{"Input dialogs", OpenDialogCallback, HelpInputDialog})
+	HelpDialog.AddOption(&Option{"Input editing keys", OpenDialogCallback, HelpInputKeysDialog})
+	HelpDialog.AddOption(&Option{"Text area dialogs", OpenDialogCallback, HelpTextAreaDialog})
 	HelpDialog.AddOption(&Option{"Exit the application", OpenDialogCallback, HelpExitDialog})
 
 	HelpExitDialog.AddOption(&Option{"No", nil, nil})
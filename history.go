@@ -0,0 +1,181 @@
+package termdialog
+
+import (
+	"bufio"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Type History represents a list of previously entered values, which an InputDialog lets the user
+// recall with the Up and Down keys and search with Ctrl+R.
+type History interface {
+	// Entries returns the entries, oldest first.
+	Entries() []string
+
+	// Add records a newly entered value.
+	Add(entry string)
+}
+
+// Type MemoryHistory is a History that is kept in memory, and so lasts as long as the program
+// does. When an entry is added again it is moved to the end rather than duplicated.
+type MemoryHistory struct {
+	entries    []string
+	maxEntries int
+}
+
+// Function NewMemoryHistory creates and returns a new, empty history that holds at most maxEntries
+// entries (or any number, if maxEntries is zero), discarding the oldest ones first.
+func NewMemoryHistory(maxEntries int) (history *MemoryHistory) {
+	return &MemoryHistory{
+		maxEntries: maxEntries,
+	}
+}
+
+func (history *MemoryHistory) Entries() (entries []string) {
+	return history.entries
+}
+
+func (history *MemoryHistory) Add(entry string) {
+	if entry == "" || strings.ContainsAny(entry, "\r\n") {
+		return
+	}
+
+	entries := make([]string, 0, len(history.entries)+1)
+	for _, e := range history.entries {
+		if e != entry {
+			entries = append(entries, e)
+		}
+	}
+	entries = append(entries, entry)
+
+	if history.maxEntries > 0 && len(entries) > history.maxEntries {
+		entries = entries[len(entries)-history.maxEntries:]
+	}
+
+	history.entries = entries
+}
+
+// Variable HistoryDir is the directory in which NewFileHistory keeps history files. If it is
+// empty, a "termdialog/history" directory inside the user's configuration directory is used.
+var HistoryDir string
+
+// Variable HistorySize is the number of entries kept by histories created with NewFileHistory.
+var HistorySize = 500
+
+// Type FileHistory is a History that is saved to a file, one entry per line, so that it persists
+// between runs of the program. The file is rewritten whenever an entry is added, merging in any
+// entries that other processes have added since.
+type FileHistory struct {
+	MemoryHistory
+	path string
+	err  error
+}
+
+// Variable ErrInvalidHistoryID is returned by NewFileHistory for IDs that do not name a file of
+// their own inside HistoryDir: "", "." and "..".
+var ErrInvalidHistoryID = errors.New("termdialog: invalid history ID")
+
+// Function NewFileHistory opens the history with the given ID in HistoryDir, so that each dialog
+// (or each kind of value) can have a history of its own. The ID is escaped to make the file name,
+// so it can contain any characters, but it cannot be empty, "." or "..".
+func NewFileHistory(id string) (history *FileHistory, err error) {
+	if id == "" || id == "." || id == ".." {
+		return nil, ErrInvalidHistoryID
+	}
+
+	dir := HistoryDir
+	if dir == "" {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = filepath.Join(configDir, "termdialog", "history")
+	}
+
+	return OpenFileHistory(filepath.Join(dir, url.PathEscape(id)), HistorySize)
+}
+
+// Function OpenFileHistory opens the history saved in the file at path, which need not exist yet.
+// At most maxEntries entries are kept (or any number, if maxEntries is zero).
+func OpenFileHistory(path string, maxEntries int) (history *FileHistory, err error) {
+	history = &FileHistory{
+		MemoryHistory: MemoryHistory{maxEntries: maxEntries},
+		path:          path,
+	}
+
+	err = history.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// Function Path returns the path of the file the history is saved in.
+func (history *FileHistory) Path() (path string) {
+	return history.path
+}
+
+// Function Err returns the error that occurred the last time the history was saved, if any.
+func (history *FileHistory) Err() (err error) {
+	return history.err
+}
+
+func (history *FileHistory) Add(entry string) {
+	history.load() // Pick up entries added by other processes; if this fails, carry on with our own.
+	history.MemoryHistory.Add(entry)
+	history.err = history.save()
+}
+
+func (history *FileHistory) load() (err error) {
+	f, err := os.Open(history.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	loaded := NewMemoryHistory(history.maxEntries)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		loaded.Add(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	history.entries = loaded.entries
+	return nil
+}
+
+func (history *FileHistory) save() (err error) {
+	err = os.MkdirAll(filepath.Dir(history.path), 0700)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename it into place, so that the history is never left
+	// half-written.
+	f, err := os.CreateTemp(filepath.Dir(history.path), filepath.Base(history.path)+".*")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.WriteString(strings.Join(history.entries, "\n") + "\n")
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), history.path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+
+	return err
+}
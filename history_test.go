@@ -0,0 +1,215 @@
+package termdialog
+
+import (
+	"context"
+	"github.com/nsf/termbox-go"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// useHistoryDir points HistoryDir at a new empty directory for the rest of the test.
+func useHistoryDir(t *testing.T) (dir string) {
+	dir = t.TempDir()
+	old := HistoryDir
+	HistoryDir = dir
+	t.Cleanup(func() { HistoryDir = old })
+	return dir
+}
+
+func TestNewFileHistoryIDs(t *testing.T) {
+	dir := useHistoryDir(t)
+
+	for _, id := range []string{"", ".", ".."} {
+		history, err := NewFileHistory(id)
+		if err != ErrInvalidHistoryID {
+			t.Errorf("NewFileHistory(%q) returned %v (path %q), want ErrInvalidHistoryID", id, err, pathOf(history))
+		}
+	}
+
+	tests := []struct {
+		id   string
+		file string
+	}{
+		{"hosts", "hosts"},
+		{"../hosts", "..%2Fhosts"},
+		{"a/b", "a%2Fb"},
+		{"...", "..."},
+	}
+	for _, test := range tests {
+		history, err := NewFileHistory(test.id)
+		if err != nil {
+			t.Errorf("NewFileHistory(%q) returned %v", test.id, err)
+			continue
+		}
+		if history.Path() != filepath.Join(dir, test.file) {
+			t.Errorf("NewFileHistory(%q) saves to %q, want %q", test.id, history.Path(), filepath.Join(dir, test.file))
+		}
+	}
+}
+
+// pathOf returns the path of history, or "" if it is nil.
+func pathOf(history *FileHistory) (path string) {
+	if history == nil {
+		return ""
+	}
+	return history.Path()
+}
+
+func TestFileHistoryLoadMergeSave(t *testing.T) {
+	useHistoryDir(t)
+
+	first, err := NewFileHistory("hosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Add("alpha")
+	first.Add("beta")
+
+	// A second process opening the same history sees what the first saved, and adds its own.
+	second, err := NewFileHistory("hosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(second.Entries(), []string{"alpha", "beta"}) {
+		t.Errorf("loaded %q, want [alpha beta]", second.Entries())
+	}
+	second.Add("gamma")
+
+	// The first merges the second's entry in when it next adds one; a repeated entry moves to the
+	// end rather than being duplicated.
+	first.Add("alpha")
+	want := []string{"beta", "gamma", "alpha"}
+	if !reflect.DeepEqual(first.Entries(), want) {
+		t.Errorf("merged %q, want %q", first.Entries(), want)
+	}
+	if first.Err() != nil || second.Err() != nil {
+		t.Errorf("saving failed: %v, %v", first.Err(), second.Err())
+	}
+
+	data, err := os.ReadFile(first.Path())
+	if err != nil || string(data) != "beta\ngamma\nalpha\n" {
+		t.Errorf("file contains %q (error %v), want %q", data, err, "beta\ngamma\nalpha\n")
+	}
+}
+
+func TestFileHistoryMaxEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	err := os.WriteFile(path, []byte("one\ntwo\n\nthree\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := OpenFileHistory(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(history.Entries(), []string{"two", "three"}) {
+		t.Errorf("loaded %q, want the newest two, [two three]", history.Entries())
+	}
+
+	history.Add("four")
+	if !reflect.DeepEqual(history.Entries(), []string{"three", "four"}) {
+		t.Errorf("after adding, %q, want [three four]", history.Entries())
+	}
+}
+
+// newHistoryDialog opens an input dialog with a history holding entries, on a new virtual screen.
+func newHistoryDialog(entries ...string) (dialog *InputDialog, stack *DialogStack, screen *VirtualScreen, submitted *string) {
+	screen = NewVirtualScreen(80, 24)
+	CurrentBackend = screen
+
+	history := NewMemoryHistory(0)
+	for _, entry := range entries {
+		history.Add(entry)
+	}
+
+	submitted = new(string)
+	dialog = NewInputDialog("Title", "Command:", 30, "", func(value string, arg interface{}) bool {
+		*submitted = value
+		return true
+	}, nil)
+	dialog.SetHistory(history)
+
+	stack = NewDialogStack()
+	stack.Open(dialog)
+	return dialog, stack, screen, submitted
+}
+
+func TestInputDialogRecallsHistory(t *testing.T) {
+	dialog, stack, screen, _ := newHistoryDialog("one", "two", "three")
+	screen.QueueString("draft")
+
+	steps := []struct {
+		key  termbox.Key
+		want string
+	}{
+		{termbox.KeyArrowUp, "three"},
+		{termbox.KeyArrowUp, "two"},
+		{termbox.KeyArrowUp, "one"},
+		{termbox.KeyArrowUp, "one"}, // there is nothing older
+		{termbox.KeyArrowDown, "two"},
+		{termbox.KeyArrowDown, "three"},
+		{termbox.KeyArrowDown, "draft"}, // back to what was being typed
+		{termbox.KeyArrowDown, "draft"},
+	}
+	for i, step := range steps {
+		screen.QueueKey(step.key)
+		stack.RunContext(context.Background())
+		if dialog.GetValue() != step.want {
+			t.Errorf("step %d: value is %q, want %q", i, dialog.GetValue(), step.want)
+		}
+	}
+}
+
+func TestInputDialogSearchesHistory(t *testing.T) {
+	dialog, stack, screen, submitted := newHistoryDialog("git status", "go test", "git commit")
+
+	screen.QueueKey(termbox.KeyCtrlR)
+	screen.QueueString("git")
+	stack.RunContext(context.Background())
+	if dialog.GetValue() != "git commit" || dialog.GetCursor() != 0 {
+		t.Errorf("searching for git found %q with the cursor at %d, want %q at 0", dialog.GetValue(), dialog.GetCursor(), "git commit")
+	}
+
+	screen.QueueKey(termbox.KeyCtrlR)
+	stack.RunContext(context.Background())
+	if dialog.GetValue() != "git status" {
+		t.Errorf("searching again found %q, want %q", dialog.GetValue(), "git status")
+	}
+
+	screen.QueueKey(termbox.KeyCtrlR)
+	stack.RunContext(context.Background())
+	if dialog.GetValue() != "git status" || !dialog.search.failed {
+		t.Errorf("searching past the oldest match gave %q (failed %v), want %q and a failed search", dialog.GetValue(), dialog.search.failed, "git status")
+	}
+
+	screen.QueueKey(termbox.KeyEnter)
+	stack.RunContext(context.Background())
+	if *submitted != "git status" {
+		t.Errorf("Enter submitted %q, want the match, %q", *submitted, "git status")
+	}
+}
+
+func TestInputDialogSearchCancelled(t *testing.T) {
+	dialog, stack, screen, _ := newHistoryDialog("git status", "go test")
+
+	screen.QueueString("draft")
+	screen.QueueKey(termbox.KeyCtrlR)
+	screen.QueueString("xyz")
+	stack.RunContext(context.Background())
+	if dialog.GetValue() != "draft" || !dialog.search.failed {
+		t.Errorf("searching for xyz gave %q (failed %v), want the value unchanged and a failed search", dialog.GetValue(), dialog.search.failed)
+	}
+
+	screen.QueueKey(termbox.KeyBackspace2)
+	screen.QueueKey(termbox.KeyBackspace2)
+	screen.QueueKey(termbox.KeyBackspace2)
+	screen.QueueString("go")
+	screen.QueueKey(termbox.KeyEsc)
+	stack.RunContext(context.Background())
+	if dialog.GetValue() != "draft" || dialog.search != nil || !stack.IsOpen(dialog) {
+		t.Errorf("Esc left %q (searching %v, open %v), want the search cancelled and %q restored", dialog.GetValue(), dialog.search != nil, stack.IsOpen(dialog), "draft")
+	}
+}
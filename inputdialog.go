@@ -1,8 +1,11 @@
 package termdialog
 
 import (
+	"errors"
 	"github.com/nsf/termbox-go"
+	"github.com/rivo/uniseg"
 	"strings"
+	"unicode"
 )
 
 /*
@@ -15,20 +18,64 @@ import (
   +-------------------+
 */
 
+// Type NewlinePolicy says what an input dialog does with line breaks in pasted text. In either case,
+// a single line break at the very end, as is often copied along with a line, is ignored.
+type NewlinePolicy int
+
+const (
+	StripNewlines  NewlinePolicy = iota // Line breaks are removed, joining the lines together.
+	RejectNewlines                      // Text with line breaks is not pasted; an error is shown instead.
+)
+
+// Variable ErrPasteNewlines is the error shown when text with line breaks is pasted into an input
+// dialog whose newline policy is RejectNewlines.
+var ErrPasteNewlines = errors.New("cannot paste more than one line")
+
 type InputDialog struct {
 	BaseDialog
-	prompt     string
-	valueWidth int
-	value      string
-	callback   func(string, interface{}) bool
-	arg        interface{}
+	prompt      string
+	valueWidth  int // the number of cells the value is displayed in
+	maxLength   int // if > 0, the maximum number of characters the value may hold
+	value       []rune
+	cursor      int // index into value; always at the start of a grapheme cluster
+	scroll      int // index into value of the first visible character
+	masked      bool
+	maskRune    rune // drawn in place of each character when masked; if 0, nothing is drawn
+	revealable  bool // whether Ctrl+R toggles revealed
+	revealed    bool
+	validator   Validator
+	invalid     error // the error from the last failed validation, shown under the field
+	history     History
+	historyPos  int            // how many entries back from the newest the value was recalled from; 0 if it was not
+	draft       []rune         // the value that was being typed before an entry was recalled
+	search      *historySearch // the Ctrl+R search in progress, if any
+	completer   Completer
+	undo        []inputState
+	lastCommand command
+	yankStart   int // where the text inserted by the last Ctrl+Y or Alt+Y starts
+	yankIndex   int // the kill ring entry that was last yanked
+	inputMask   *InputMask
+	slots       []rune // the characters entered into the input mask's slots, 0 where empty
+	slot        int    // the slot the cursor is at, when there is an input mask
+	newlines    NewlinePolicy
+	callback    func(string, interface{}) bool
+	arg         interface{}
+}
+
+// historySearch holds the state of a reverse incremental search through an input dialog's history.
+type historySearch struct {
+	query    []rune
+	match    int    // index into the history entries of the current match
+	failed   bool   // whether nothing matches the query
+	original []rune // the value before the search started, restored if it is cancelled
 }
 
 func NewInputDialog(title string, prompt string, valueWidth int, valueInit string, callback func(string, interface{}) bool, arg interface{}) (dialog *InputDialog) {
 	dialog = &InputDialog{
 		prompt:     prompt,
 		valueWidth: valueWidth,
-		value:      valueInit,
+		value:      []rune(valueInit),
+		maskRune:   '*',
 		callback:   callback,
 		arg:        arg,
 	}
@@ -36,6 +83,14 @@ func NewInputDialog(title string, prompt string, valueWidth int, valueInit strin
 	dialog.BaseDialog.title = title
 	dialog.BaseDialog.metricsDirty = true
 	dialog.BaseDialog.theme = DefaultTheme
+	dialog.cursor = len(dialog.value)
+	return dialog
+}
+
+// Function NewPasswordDialog creates an input dialog that masks the entered value with asterisks.
+func NewPasswordDialog(title string, prompt string, valueWidth int, callback func(string, interface{}) bool, arg interface{}) (dialog *InputDialog) {
+	dialog = NewInputDialog(title, prompt, valueWidth, "", callback, arg)
+	dialog.SetMasked(true)
 	return dialog
 }
 
@@ -57,12 +112,142 @@ func (dialog *InputDialog) SetValueWidth(valueWidth int) {
 	dialog.metricsDirty = true
 }
 
+func (dialog *InputDialog) GetMaxLength() (maxLength int) {
+	return dialog.maxLength
+}
+
+// Function SetMaxLength limits the number of characters (grapheme clusters) that can be entered.
+// If it is zero (the default), the value can be any length, and the field scrolls horizontally
+// once it is longer than the value width.
+func (dialog *InputDialog) SetMaxLength(maxLength int) {
+	dialog.maxLength = maxLength
+}
+
+func (dialog *InputDialog) GetMasked() (masked bool) {
+	return dialog.masked
+}
+
+// Function SetMasked sets whether the value is hidden behind the mask rune as it is typed. In masked
+// mode, the dialog also wipes its copy of the value once the callback has been called, and whenever
+// it is closed (including when it is cancelled or aborted), so the callback (or GetValue, from
+// inside it) is the only chance to read it. Edits cannot be undone and cut text is not added to the
+// kill ring, so that no other copies of the value are kept; switching to masked mode forgets the
+// undo states and kill ring entries recorded before the switch, as they could hold the value.
+func (dialog *InputDialog) SetMasked(masked bool) {
+	dialog.masked = masked
+	dialog.revealed = false
+
+	if masked {
+		dialog.clearUndo()
+		clearKillRing()
+	}
+}
+
+func (dialog *InputDialog) GetMaskRune() (maskRune rune) {
+	return dialog.maskRune
+}
+
+// Function SetMaskRune sets the rune drawn in place of each character in masked mode. If it is 0,
+// nothing at all is drawn, so that not even the length of the value is revealed.
+func (dialog *InputDialog) SetMaskRune(maskRune rune) {
+	dialog.maskRune = maskRune
+}
+
+func (dialog *InputDialog) GetRevealable() (revealable bool) {
+	return dialog.revealable
+}
+
+// Function SetRevealable sets whether Ctrl+R can be used to toggle between showing and masking the
+// value in masked mode.
+func (dialog *InputDialog) SetRevealable(revealable bool) {
+	dialog.revealable = revealable
+}
+
+func (dialog *InputDialog) GetValidator() (validator Validator) {
+	return dialog.validator
+}
+
+// Function SetValidator sets a function that checks the value before it is passed to the callback.
+// If it returns an error, Enter is refused and the error is displayed under the field until the
+// value is corrected.
+func (dialog *InputDialog) SetValidator(validator Validator) {
+	dialog.validator = validator
+	dialog.invalid = nil
+	dialog.metricsDirty = true
+}
+
+func (dialog *InputDialog) GetHistory() (history History) {
+	return dialog.history
+}
+
+// Function SetHistory sets the history of previous values, which the user can recall with the Up and
+// Down keys and search with Ctrl+R. Confirmed values are added to it. History is not used in masked
+// mode, so that passwords are never recorded.
+func (dialog *InputDialog) SetHistory(history History) {
+	dialog.history = history
+	dialog.historyPos = 0
+	dialog.draft = nil
+	dialog.search = nil
+	dialog.metricsDirty = true
+}
+
+func (dialog *InputDialog) GetCompleter() (completer Completer) {
+	return dialog.completer
+}
+
+// Function SetCompleter sets the source of completions for the Tab key. Tab completes as much of
+// the value as the candidates have in common; pressing it again lists them in a popup. Completion
+// is not used in masked mode.
+func (dialog *InputDialog) SetCompleter(completer Completer) {
+	dialog.completer = completer
+}
+
+// Function GetNewlinePolicy returns what happens to line breaks in pasted text.
+func (dialog *InputDialog) GetNewlinePolicy() (policy NewlinePolicy) {
+	return dialog.newlines
+}
+
+// Function SetNewlinePolicy sets what happens to line breaks in pasted text. The default is
+// StripNewlines. Pastes only arrive in one piece with backends that support bracketed paste;
+// otherwise each character is typed in turn, and a line break submits the value as Enter would.
+func (dialog *InputDialog) SetNewlinePolicy(policy NewlinePolicy) {
+	dialog.newlines = policy
+}
+
+// Function GetValue returns the value. If there is an input mask, it is formatted with the mask's
+// separators.
 func (dialog *InputDialog) GetValue() (value string) {
-	return dialog.value
+	if dialog.inputMask != nil {
+		return dialog.formatted()
+	}
+	return string(dialog.value)
 }
 
+// Function SetValue sets the value. If there is an input mask, the characters of value are entered
+// into it as if they had been typed, so either a raw or a formatted value may be given.
 func (dialog *InputDialog) SetValue(value string) {
-	dialog.value = value
+	dialog.clearUndo()
+	if dialog.inputMask != nil {
+		dialog.setMaskValue(value)
+		return
+	}
+
+	dialog.value = []rune(value)
+	dialog.cursor = len(dialog.value)
+}
+
+// Function GetCursor returns the position of the cursor within the value, counted in runes.
+func (dialog *InputDialog) GetCursor() (cursor int) {
+	return dialog.cursor
+}
+
+// Function SetCursor moves the cursor to the given position within the value, counted in runes.
+// If the position lies inside a grapheme cluster, the cursor is moved to the end of it.
+func (dialog *InputDialog) SetCursor(cursor int) {
+	if cursor < 0 {
+		cursor = 0
+	}
+	dialog.cursor = snapToBoundary(graphemeBoundaries(dialog.value), cursor)
 }
 
 func (dialog *InputDialog) GetCallback() (callback func(string, interface{}) bool) {
@@ -82,32 +267,301 @@ func (dialog *InputDialog) SetCallbackArg(arg interface{}) {
 }
 
 func (dialog *InputDialog) CalcMetrics() {
-	windowWidth, windowHeight := termbox.Size()
-
-	maxWidth := len(dialog.prompt) + 1 + dialog.valueWidth
-	if len(dialog.BaseDialog.title) > maxWidth {
-		maxWidth = len(dialog.BaseDialog.title)
+	maxWidth := stringWidth(dialog.prompt) + 1 + dialog.valueWidth
+	if stringWidth(dialog.BaseDialog.title) > maxWidth {
+		maxWidth = stringWidth(dialog.BaseDialog.title)
+	}
+	if dialog.invalid != nil && stringWidth(dialog.invalid.Error()) > maxWidth {
+		maxWidth = stringWidth(dialog.invalid.Error())
 	}
 
 	dialog.width = 6 + maxWidth // 6 = "|  " + "  |"
 	dialog.height = 7
 
-	dialog.x = (windowWidth / 2) - (dialog.width / 2)
-	dialog.y = (windowHeight / 2) - (dialog.height / 2)
+	if dialog.validator != nil || dialog.inputMask != nil || dialog.historyEnabled() || dialog.invalid != nil {
+		dialog.height++ // Make room for error messages and history searches under the field
+	}
+
+	dialog.fitToScreen(6+minContentWidth, 7)
 
 	dialog.metricsDirty = false
 }
 
 func (dialog *InputDialog) Open() {
-	BaseDialogOpen(dialog)
+	if !BaseDialogOpen(dialog) {
+		return
+	}
+
+	dialog.drawContent(dialog.x+3, dialog.y+4, dialog.prompt, dialog.theme.InactiveItem)
+
+	dialog.scrollToCursor()
+	dialog.drawField(dialog.displayText(dialog.scroll, len(dialog.value)))
 
-	DrawString(dialog.x+3, dialog.y+4, dialog.prompt, dialog.theme.InactiveItem)
+	if dialog.search != nil {
+		dialog.drawContent(dialog.x+3, dialog.y+5, dialog.searchStatus(), dialog.theme.InactiveItem)
+	} else if dialog.invalid != nil {
+		dialog.drawContent(dialog.x+3, dialog.y+5, dialog.invalid.Error(), dialog.theme.Error)
+	}
+}
 
-	v := dialog.value + strings.Repeat("_", dialog.valueWidth-len(dialog.value))
-	DrawString(dialog.x+4+len(dialog.prompt), dialog.y+4, v, dialog.theme.ActiveItem)
+// hidden returns whether the value is currently being masked.
+func (dialog *InputDialog) hidden() (hidden bool) {
+	return dialog.masked && !dialog.revealed
+}
+
+// displayText returns value[from:to] as it is displayed, i.e. with every character replaced by the
+// mask rune if the value is hidden.
+func (dialog *InputDialog) displayText(from int, to int) (text string) {
+	if !dialog.hidden() {
+		return string(dialog.value[from:to])
+	}
+	if dialog.maskRune == 0 {
+		return ""
+	}
+
+	n := 0
+	for _, b := range graphemeBoundaries(dialog.value) {
+		if b >= from && b < to {
+			n++
+		}
+	}
+	return strings.Repeat(string(dialog.maskRune), n)
+}
+
+// wipe overwrites the value with zeroes and empties it.
+func (dialog *InputDialog) wipe() {
+	wipeRunes(dialog.value[:cap(dialog.value)])
+	dialog.value = dialog.value[:0]
+	dialog.cursor = 0
+	dialog.scroll = 0
+	dialog.clearUndo()
+
+	if dialog.inputMask != nil {
+		wipeRunes(dialog.slots)
+		dialog.slot = 0
+		dialog.renderMask()
+	}
+}
+
+// wipeRunes overwrites a slice with zeroes.
+func wipeRunes(runes []rune) {
+	for i := range runes {
+		runes[i] = 0
+	}
+}
+
+// drawField draws the visible part of the value (text, which starts at the scroll position) into
+// the field, padded with underscores and with arrows at either end if it has been scrolled.
+func (dialog *InputDialog) drawField(text string) {
+	x := dialog.fieldX()
+	y := dialog.y + 4
+	style := dialog.theme.ActiveItem
+	left, avail, right := dialog.fieldLayout(dialog.scroll)
+
+	if left {
+		dialog.drawContent(x, y, "<", style)
+		x++
+	}
+
+	graphemes := uniseg.NewGraphemes(text)
+	for graphemes.Next() && graphemes.Width() <= avail {
+		dialog.drawContent(x, y, graphemes.Str(), style)
+		x += graphemes.Width()
+		avail -= graphemes.Width()
+	}
+
+	padding := "_"
+	if dialog.inputMask != nil {
+		padding = " " // The mask shows its own blanks
+	}
+	dialog.drawContent(x, y, strings.Repeat(padding, avail), style)
+
+	if right {
+		dialog.drawContent(x+avail, y, ">", style)
+	}
+}
+
+// fieldWidth returns the number of cells available to display the value in, which is less than
+// the value width if the dialog has been shrunk to fit on the screen.
+func (dialog *InputDialog) fieldWidth() (width int) {
+	width = dialog.valueWidth
+	if room := dialog.x + dialog.width - 3 - dialog.fieldX(); room < width {
+		width = room
+	}
+	if width < 1 {
+		width = 1
+	}
+	return width
+}
+
+// fieldLayout works out how the field would be laid out if scrolled to the given position:
+// whether there are arrows at the left and right ends, and how many cells are left for text.
+// One cell is always kept free after the text so that the cursor can be placed after it.
+func (dialog *InputDialog) fieldLayout(scroll int) (left bool, avail int, right bool) {
+	avail = dialog.fieldWidth()
+
+	left = scroll > 0 && avail > 2
+	if left {
+		avail--
+	}
+
+	right = stringWidth(dialog.displayText(scroll, len(dialog.value)))+1 > avail && avail > 2
+	if right {
+		avail--
+	}
+
+	return left, avail, right
+}
+
+// cursorFits returns whether the cursor would be visible if the field were scrolled to the given
+// position.
+func (dialog *InputDialog) cursorFits(scroll int) (fits bool) {
+	_, avail, _ := dialog.fieldLayout(scroll)
+	return stringWidth(dialog.displayText(scroll, dialog.cursor))+1 <= avail
+}
+
+// scrollToCursor adjusts the scroll position so that the cursor is visible, scrolling back to
+// the left again if that makes more of the value visible.
+func (dialog *InputDialog) scrollToCursor() {
+	boundaries := graphemeBoundaries(dialog.value)
+
+	if dialog.scroll > dialog.cursor {
+		dialog.scroll = dialog.cursor
+	}
+	dialog.scroll = snapToBoundary(boundaries, dialog.scroll)
+
+	for dialog.scroll < dialog.cursor && !dialog.cursorFits(dialog.scroll) {
+		dialog.scroll = nextBoundary(boundaries, dialog.scroll)
+	}
+
+	for dialog.scroll > 0 {
+		prev := prevBoundary(boundaries, dialog.scroll)
+		_, _, right := dialog.fieldLayout(prev)
+		if right || !dialog.cursorFits(prev) {
+			break
+		}
+		dialog.scroll = prev
+	}
+}
+
+// fieldX returns the screen column at which the value starts.
+func (dialog *InputDialog) fieldX() (x int) {
+	return dialog.x + 4 + stringWidth(dialog.prompt)
+}
+
+// Function CursorPosition returns the screen position of the text cursor, which is hidden if it has
+// been truncated away.
+func (dialog *InputDialog) CursorPosition() (x int, y int, visible bool) {
+	if dialog.search != nil {
+		// The cursor sits at the end of the search query, under the field.
+		x = dialog.x + 3 + stringWidth(dialog.searchStatus())
+		y = dialog.y + 5
+		visible = !dialog.tooSmall && x < dialog.x+dialog.width-3 && y < dialog.y+dialog.height-1
+		return x, y, visible
+	}
+
+	dialog.scrollToCursor()
+	left, _, _ := dialog.fieldLayout(dialog.scroll)
+
+	x = dialog.fieldX() + stringWidth(dialog.displayText(dialog.scroll, dialog.cursor))
+	if left {
+		x++
+	}
+	y = dialog.y + 4
+	visible = !dialog.tooSmall && x < dialog.x+dialog.width-3
+	return x, y, visible
+}
+
+// insert inserts text at the cursor, provided the value has room for it.
+func (dialog *InputDialog) insert(text string) {
+	runes := []rune(text)
+
+	value := make([]rune, 0, len(dialog.value)+len(runes))
+	value = append(value, dialog.value[:dialog.cursor]...)
+	value = append(value, runes...)
+	value = append(value, dialog.value[dialog.cursor:]...)
+
+	// Whichever buffer is discarded is wiped, so that masked values do not linger in memory.
+	boundaries := graphemeBoundaries(value)
+	if dialog.maxLength > 0 && len(boundaries)-1 > dialog.maxLength {
+		wipeRunes(value)
+		return
+	}
+
+	wipeRunes(dialog.value)
+	dialog.value = value
+	dialog.cursor = snapToBoundary(boundaries, dialog.cursor+len(runes))
+}
+
+// typeText inserts text that has been typed. A run of typing is undone all at once.
+func (dialog *InputDialog) typeText(text string, previous command) {
+	if previous != commandInsert {
+		dialog.saveUndo()
+	}
+	dialog.insert(text)
+	dialog.lastCommand = commandInsert
+}
+
+// remove deletes the runes between from and to.
+func (dialog *InputDialog) remove(from int, to int) {
+	n := len(dialog.value)
+	dialog.value = append(dialog.value[:from], dialog.value[to:]...)
+	wipeRunes(dialog.value[len(dialog.value):n])
+	if dialog.cursor > to {
+		dialog.cursor -= to - from
+	} else if dialog.cursor > from {
+		dialog.cursor = from
+	}
+}
+
+// isWordChar returns whether the grapheme cluster starting at pos is part of a word, for the
+// purposes of word-wise cursor movement.
+func (dialog *InputDialog) isWordChar(pos int) (isWord bool) {
+	c := dialog.value[pos]
+	return unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// wordLeft returns the position of the start of the word before the cursor. If the value is
+// hidden, it returns the start of the value, since stopping anywhere else would give away where
+// the spaces are.
+func (dialog *InputDialog) wordLeft(boundaries []int) (pos int) {
+	if dialog.hidden() {
+		return 0
+	}
+
+	pos = dialog.cursor
+	for pos > 0 && !dialog.isWordChar(prevBoundary(boundaries, pos)) {
+		pos = prevBoundary(boundaries, pos)
+	}
+	for pos > 0 && dialog.isWordChar(prevBoundary(boundaries, pos)) {
+		pos = prevBoundary(boundaries, pos)
+	}
+	return pos
+}
+
+// wordRight returns the position of the end of the word after the cursor, or the end of the value
+// if it is hidden.
+func (dialog *InputDialog) wordRight(boundaries []int) (pos int) {
+	if dialog.hidden() {
+		return len(dialog.value)
+	}
+
+	pos = dialog.cursor
+	for pos < len(dialog.value) && !dialog.isWordChar(pos) {
+		pos = nextBoundary(boundaries, pos)
+	}
+	for pos < len(dialog.value) && dialog.isWordChar(pos) {
+		pos = nextBoundary(boundaries, pos)
+	}
+	return pos
 }
 
 func (dialog *InputDialog) HandleEvent(event termbox.Event) (handled bool, shouldClose bool) {
+	if dialog.search != nil && event.Type == termbox.EventKey && dialog.handleSearchKey(event) {
+		dialog.revalidate()
+		return true, false
+	}
+
 	handled, shouldClose = BaseDialogHandleEvent(dialog, event)
 	if handled {
 		return
@@ -115,36 +569,429 @@ func (dialog *InputDialog) HandleEvent(event termbox.Event) (handled bool, shoul
 
 	switch event.Type {
 	case termbox.EventKey:
+		if dialog.inputMask != nil {
+			return dialog.handleMaskKey(event)
+		}
+
+		previous := dialog.lastCommand
+		dialog.lastCommand = commandOther
+		boundaries := graphemeBoundaries(dialog.value)
+
 		if event.Ch == 0 {
+			byWord := event.Mod&(ModCtrl|termbox.ModAlt) != 0
+
 			switch event.Key {
 			case termbox.KeyEnter:
-				shouldClose = true
-				if dialog.callback != nil {
-					shouldClose = dialog.callback(dialog.value, dialog.arg)
+				return true, dialog.submit()
+
+			case termbox.KeyBackspace, termbox.KeyBackspace2:
+				if event.Mod&termbox.ModAlt != 0 {
+					dialog.kill(dialog.wordLeft(boundaries), dialog.cursor, previous)
+				} else {
+					dialog.saveUndo()
+					dialog.remove(prevBoundary(boundaries, dialog.cursor), dialog.cursor)
 				}
 
-				return true, shouldClose
+			case termbox.KeyDelete, termbox.KeyCtrlD:
+				dialog.saveUndo()
+				dialog.remove(dialog.cursor, nextBoundary(boundaries, dialog.cursor))
 
-			case termbox.KeyBackspace, termbox.KeyBackspace2:
-				if len(dialog.value) > 0 {
-					dialog.value = dialog.value[:len(dialog.value)-1]
+			case termbox.KeyCtrlK:
+				dialog.kill(dialog.cursor, len(dialog.value), previous)
+
+			case termbox.KeyCtrlU:
+				dialog.kill(0, dialog.cursor, previous)
+
+			case termbox.KeyCtrlW:
+				dialog.kill(dialog.spaceWordLeft(), dialog.cursor, previous)
+
+			case termbox.KeyCtrlY:
+				dialog.yank()
+
+			case termbox.KeyCtrlUnderscore:
+				dialog.undoEdit()
+
+			case termbox.KeyCtrlR:
+				if dialog.masked && dialog.revealable {
+					dialog.revealed = !dialog.revealed
+				} else if dialog.historyEnabled() {
+					dialog.startSearch()
+				} else {
+					return false, false
 				}
 
-				return true, false
+			case termbox.KeyTab:
+				if !dialog.complete(previous == commandComplete) {
+					return false, false
+				}
 
-			case termbox.KeySpace:
-				if len(dialog.value) < dialog.valueWidth {
-					dialog.value += " "
+			case termbox.KeyArrowUp:
+				if !dialog.recall(1) {
+					return false, false
 				}
+
+			case termbox.KeyArrowDown:
+				if !dialog.recall(-1) {
+					return false, false
+				}
+
+			case termbox.KeyArrowLeft:
+				if byWord {
+					dialog.cursor = dialog.wordLeft(boundaries)
+				} else {
+					dialog.cursor = prevBoundary(boundaries, dialog.cursor)
+				}
+
+			case termbox.KeyArrowRight:
+				if byWord {
+					dialog.cursor = dialog.wordRight(boundaries)
+				} else {
+					dialog.cursor = nextBoundary(boundaries, dialog.cursor)
+				}
+
+			case termbox.KeyCtrlB:
+				dialog.cursor = prevBoundary(boundaries, dialog.cursor)
+
+			case termbox.KeyCtrlF:
+				dialog.cursor = nextBoundary(boundaries, dialog.cursor)
+
+			case termbox.KeyHome, termbox.KeyCtrlA:
+				dialog.cursor = 0
+
+			case termbox.KeyEnd, termbox.KeyCtrlE:
+				dialog.cursor = len(dialog.value)
+
+			case termbox.KeySpace:
+				dialog.typeText(" ", previous)
+
+			default:
+				return false, false
 			}
 
-		} else {
-			if len(dialog.value) < dialog.valueWidth {
-				dialog.value += string(event.Ch)
+		} else if event.Mod&termbox.ModAlt != 0 {
+			switch event.Ch {
+			case 'b':
+				dialog.cursor = dialog.wordLeft(boundaries)
+
+			case 'f':
+				dialog.cursor = dialog.wordRight(boundaries)
+
+			case 'd':
+				dialog.kill(dialog.cursor, dialog.wordRight(boundaries), previous)
+
+			case 'y':
+				dialog.yankPop(previous)
+
+			default:
+				return false, false
 			}
 
-			return true, false
+		} else {
+			dialog.typeText(string(event.Ch), previous)
 		}
+
+		dialog.revalidate()
+		return true, false
 	}
 	return false, false
 }
+
+// submit validates the value and passes it to the callback, returning whether the dialog should
+// close.
+func (dialog *InputDialog) submit() (shouldClose bool) {
+	dialog.setInvalid(dialog.validate())
+	if dialog.invalid != nil {
+		return false
+	}
+
+	value := dialog.GetValue()
+	shouldClose = true
+	if dialog.callback != nil {
+		shouldClose = dialog.callback(value, dialog.arg)
+	}
+	if shouldClose {
+		dialog.result = Confirmed
+		if dialog.historyEnabled() {
+			dialog.history.Add(value)
+			dialog.historyPos = 0
+			dialog.draft = nil
+		}
+	}
+	if dialog.masked {
+		dialog.wipe()
+		dialog.revealed = false
+	}
+
+	return shouldClose
+}
+
+// handleClose wipes the value of a masked dialog when it is closed without being submitted.
+func (dialog *InputDialog) handleClose() {
+	if dialog.masked {
+		dialog.wipe()
+		dialog.revealed = false
+	}
+}
+
+// revalidate updates the displayed error after the value has been edited, once a submission has
+// been refused.
+func (dialog *InputDialog) revalidate() {
+	if dialog.invalid != nil {
+		dialog.setInvalid(dialog.validate())
+	}
+}
+
+// validate checks that the input mask is complete and that the validator accepts the value.
+func (dialog *InputDialog) validate() (err error) {
+	if dialog.inputMask != nil && !dialog.maskComplete() {
+		return ErrIncomplete
+	}
+	if dialog.validator != nil {
+		return dialog.validator(dialog.GetValue())
+	}
+	return nil
+}
+
+// setInvalid sets the displayed validation error, resizing the dialog to fit it.
+func (dialog *InputDialog) setInvalid(err error) {
+	dialog.invalid = err
+	dialog.metricsDirty = true
+}
+
+// historyEnabled returns whether the Up and Down keys and Ctrl+R work with the history.
+func (dialog *InputDialog) historyEnabled() (enabled bool) {
+	return dialog.history != nil && !dialog.masked && dialog.inputMask == nil
+}
+
+// recall replaces the value with the entry delta steps further back in the history, or with the
+// value that was being typed if it steps forward past the newest entry. It returns false if there
+// is no history.
+func (dialog *InputDialog) recall(delta int) (ok bool) {
+	if !dialog.historyEnabled() {
+		return false
+	}
+
+	entries := dialog.history.Entries()
+	pos := dialog.historyPos + delta
+	if pos < 0 || pos > len(entries) {
+		return true
+	}
+
+	if dialog.historyPos == 0 {
+		dialog.draft = dialog.value
+	}
+	dialog.historyPos = pos
+
+	if pos == 0 {
+		dialog.value = dialog.draft
+		dialog.draft = nil
+	} else {
+		dialog.value = []rune(entries[len(entries)-pos])
+	}
+	dialog.cursor = len(dialog.value)
+	return true
+}
+
+// startSearch begins a reverse incremental search through the history.
+func (dialog *InputDialog) startSearch() {
+	dialog.search = &historySearch{
+		match:    len(dialog.history.Entries()),
+		original: dialog.value,
+	}
+}
+
+// searchStatus returns the line shown under the field during a history search.
+func (dialog *InputDialog) searchStatus() (status string) {
+	if dialog.search.failed {
+		return "failed search: " + string(dialog.search.query)
+	}
+	return "search: " + string(dialog.search.query)
+}
+
+// searchFrom searches backwards through the history, starting with the entry at index start, for
+// one containing the query, and makes it the value.
+func (dialog *InputDialog) searchFrom(start int) {
+	search := dialog.search
+	entries := dialog.history.Entries()
+	query := string(search.query)
+
+	if query == "" {
+		search.failed = false
+		return
+	}
+
+	if start >= len(entries) {
+		start = len(entries) - 1
+	}
+	for i := start; i >= 0; i-- {
+		if index := strings.Index(entries[i], query); index >= 0 {
+			search.match = i
+			search.failed = false
+			dialog.value = []rune(entries[i])
+			dialog.SetCursor(len([]rune(entries[i][:index])))
+			return
+		}
+	}
+
+	search.failed = true
+}
+
+// handleSearchKey handles a keypress during a history search. Keys that do not edit the search end
+// it, keeping the value that was found, and are then handled as usual (so Enter submits it); Esc
+// and Ctrl+G instead cancel the search and restore the original value. It returns false if the key
+// should be handled as usual.
+func (dialog *InputDialog) handleSearchKey(event termbox.Event) (handled bool) {
+	search := dialog.search
+
+	if event.Ch != 0 {
+		search.query = append(search.query, event.Ch)
+		dialog.searchFrom(search.match)
+		return true
+	}
+
+	switch event.Key {
+	case termbox.KeySpace:
+		search.query = append(search.query, ' ')
+		dialog.searchFrom(search.match)
+
+	case termbox.KeyBackspace, termbox.KeyBackspace2:
+		search.query = search.query[:prevBoundary(graphemeBoundaries(search.query), len(search.query))]
+		dialog.searchFrom(len(dialog.history.Entries()) - 1)
+
+	case termbox.KeyCtrlR:
+		dialog.searchFrom(search.match - 1)
+
+	case termbox.KeyEsc, termbox.KeyCtrlG:
+		dialog.value = search.original
+		dialog.cursor = len(dialog.value)
+		dialog.search = nil
+
+	default:
+		dialog.search = nil
+		return false
+	}
+
+	return true
+}
+
+// complete asks the completer for candidates and completes the text before the cursor as far as
+// they agree. If they agree no further and listing is true, they are offered in a popup instead. It
+// returns false if there is no completer.
+func (dialog *InputDialog) complete(listing bool) (ok bool) {
+	if dialog.completer == nil || dialog.masked || dialog.inputMask != nil {
+		return false
+	}
+
+	var candidates []string
+	for _, candidate := range dialog.completer.Complete(string(dialog.value), dialog.cursor) {
+		if dialog.completionFits([]rune(candidate)) {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return true
+
+	case 1:
+		dialog.applyCompletion([]rune(candidates[0]))
+		return true
+	}
+
+	dialog.lastCommand = commandComplete
+	if prefix := commonPrefix(candidates); len(prefix) > dialog.cursor {
+		dialog.applyCompletion(prefix)
+	} else if listing {
+		dialog.GetLastDialogStack().Open(newCompletionPopup(dialog, candidates))
+	}
+	return true
+}
+
+// completionFits returns whether replacing the text before the cursor with completion would keep
+// the value within the maximum length.
+func (dialog *InputDialog) completionFits(completion []rune) (fits bool) {
+	if dialog.maxLength <= 0 {
+		return true
+	}
+
+	length := len(graphemeBoundaries(completion)) - 1
+	length += len(graphemeBoundaries(dialog.value[dialog.cursor:])) - 1
+	return length <= dialog.maxLength
+}
+
+// applyCompletion replaces the text before the cursor with completion. It does nothing if the value
+// would then be longer than the maximum length.
+func (dialog *InputDialog) applyCompletion(completion []rune) {
+	if !dialog.completionFits(completion) {
+		return
+	}
+
+	dialog.saveUndo()
+
+	value := make([]rune, 0, len(completion)+len(dialog.value)-dialog.cursor)
+	value = append(value, completion...)
+	value = append(value, dialog.value[dialog.cursor:]...)
+
+	dialog.value = value
+	dialog.cursor = len(completion)
+	dialog.revalidate()
+}
+
+// Function HandlePaste inserts pasted text at the cursor in one go, as a single edit. Line breaks
+// are dealt with according to the newline policy, tabs become spaces and other control characters
+// are dropped. If the value has a maximum length, as much of the text as fits is inserted.
+func (dialog *InputDialog) HandlePaste(text string) (handled bool, shouldClose bool) {
+	dialog.search = nil
+	dialog.lastCommand = commandOther
+
+	text = strings.TrimSuffix(strings.TrimSuffix(text, "\n"), "\r")
+	if strings.ContainsAny(text, "\r\n") && dialog.newlines == RejectNewlines {
+		dialog.setInvalid(ErrPasteNewlines)
+		return true, false
+	}
+
+	text = strings.Map(func(c rune) rune {
+		if c == '\t' {
+			return ' '
+		}
+		if unicode.IsControl(c) {
+			return -1
+		}
+		return c
+	}, text)
+
+	if dialog.inputMask != nil {
+		for _, c := range text {
+			dialog.typeMask(c)
+		}
+		dialog.renderMask()
+	} else {
+		dialog.saveUndo()
+		dialog.insert(dialog.truncateToFit(text))
+	}
+
+	if dialog.invalid == ErrPasteNewlines {
+		dialog.setInvalid(nil)
+	}
+	dialog.revalidate()
+	return true, false
+}
+
+// truncateToFit returns as much of text as can be inserted without exceeding the maximum length.
+func (dialog *InputDialog) truncateToFit(text string) (fitting string) {
+	if dialog.maxLength <= 0 {
+		return text
+	}
+
+	room := dialog.maxLength - (len(graphemeBoundaries(dialog.value)) - 1)
+	if room <= 0 {
+		return ""
+	}
+
+	runes := []rune(text)
+	boundaries := graphemeBoundaries(runes)
+	if len(boundaries)-1 > room {
+		runes = runes[:boundaries[room]]
+	}
+	return string(runes)
+}
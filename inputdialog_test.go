@@ -0,0 +1,210 @@
+package termdialog
+
+import (
+	"context"
+	"github.com/nsf/termbox-go"
+	"strings"
+	"testing"
+)
+
+func TestInputDialogGraphemeClusters(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+	}{
+		{"precomposed", "\u00e9", 1},
+		{"combining", "e\u0301", 1},
+		{"wide", "中", 2},
+	}
+
+	for _, test := range tests {
+		screen := NewVirtualScreen(80, 24)
+		CurrentBackend = screen
+		stack := NewDialogStack()
+		dialog := NewInputDialog("Title", "Value:", 20, "", nil, nil)
+		stack.Open(dialog)
+
+		run := func(keys ...termbox.Key) {
+			for _, key := range keys {
+				screen.QueueKey(key)
+			}
+			stack.RunContext(context.Background())
+		}
+
+		// Typing: the cluster is drawn in the field, and the cursor moves past its width.
+		screen.QueueString("a" + test.text + "b")
+		run(termbox.KeyArrowLeft)
+		if got := dialog.GetValue(); got != "a"+test.text+"b" {
+			t.Errorf("%s: value after typing is %q, want %q", test.name, got, "a"+test.text+"b")
+		}
+		if line := screen.Line(dialog.GetY() + 4); !strings.Contains(line, "a"+test.text+"b") {
+			t.Errorf("%s: field shows %q, want it to contain %q", test.name, line, "a"+test.text+"b")
+		}
+		x, _ := screen.Cursor()
+		if want := dialog.fieldX() + 1 + test.width; x != want {
+			t.Errorf("%s: cursor at x=%d after typing, want %d", test.name, x, want)
+		}
+
+		// Backspace deletes the whole cluster.
+		run(termbox.KeyBackspace2)
+		if got := dialog.GetValue(); got != "ab" {
+			t.Errorf("%s: value after Backspace is %q, want %q", test.name, got, "ab")
+		}
+		x, _ = screen.Cursor()
+		if want := dialog.fieldX() + 1; x != want {
+			t.Errorf("%s: cursor at x=%d after Backspace, want %d", test.name, x, want)
+		}
+
+		// Delete deletes the whole cluster too.
+		dialog.SetValue("a" + test.text + "b")
+		run(termbox.KeyHome, termbox.KeyArrowRight, termbox.KeyDelete)
+		if got := dialog.GetValue(); got != "ab" {
+			t.Errorf("%s: value after Delete is %q, want %q", test.name, got, "ab")
+		}
+
+		// The field is padded to the same width whatever the cluster's width.
+		dialog.SetValue(test.text)
+		run()
+		line := screen.Line(dialog.GetY() + 4)
+		field := line[strings.Index(line, test.text):]
+		if got := stringWidth(strings.TrimRight(field, " │")); got != 20 {
+			t.Errorf("%s: field %q is %d cells wide, want 20", test.name, field, got)
+		}
+	}
+}
+
+func TestCombiningMarksReachBackend(t *testing.T) {
+	screen := NewVirtualScreen(80, 24)
+	CurrentBackend = screen
+	stack := NewDialogStack()
+	dialog := NewInputDialog("Title", "Value:", 20, "e\u0301", nil, nil)
+	stack.Open(dialog)
+	stack.Draw()
+
+	x, y := dialog.fieldX(), dialog.GetY()+4
+	if ch, combining := screen.Cell(x, y).Ch, screen.Combining(x, y); ch != 'e' || string(combining) != "\u0301" {
+		t.Errorf("cell holds %q with combining marks %q, want 'e' with %q", ch, combining, "\u0301")
+	}
+}
+
+func TestMaskedInputDialogWipedOnClose(t *testing.T) {
+	tests := []struct {
+		name  string
+		close func(stack *DialogStack, screen *VirtualScreen)
+	}{
+		{"Esc", func(stack *DialogStack, screen *VirtualScreen) {
+			screen.QueueKey(termbox.KeyEsc)
+			stack.RunContext(context.Background())
+		}},
+		{"Stop", func(stack *DialogStack, screen *VirtualScreen) {
+			stack.Stop()
+		}},
+		{"cancelled context", func(stack *DialogStack, screen *VirtualScreen) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			stack.RunContext(ctx)
+		}},
+	}
+
+	for _, test := range tests {
+		screen := NewVirtualScreen(80, 24)
+		CurrentBackend = screen
+		stack := NewDialogStack()
+		dialog := NewInputDialog("Title", "Password:", 20, "", nil, nil)
+		dialog.SetMasked(true)
+		stack.Open(dialog)
+
+		screen.QueueString("secret")
+		screen.QueueKey(termbox.KeyBackspace2)
+		screen.QueueKey(termbox.KeyCtrlU)
+		screen.QueueString("secre")
+		stack.RunContext(context.Background())
+		if len(dialog.undo) != 0 {
+			t.Errorf("%s: %d undo states recorded in masked mode", test.name, len(dialog.undo))
+		}
+		for _, entry := range killRing {
+			if strings.Contains(string(entry), "secre") {
+				t.Errorf("%s: masked text %q added to the kill ring", test.name, entry)
+			}
+		}
+
+		buffer := dialog.value[:cap(dialog.value)]
+		test.close(stack, screen)
+		if stack.IsOpen(dialog) {
+			t.Fatalf("%s: dialog still open", test.name)
+		}
+		if dialog.GetValue() != "" || strings.ContainsAny(string(buffer), "secr") {
+			t.Errorf("%s: value %q (buffer %q) not wiped on close", test.name, dialog.GetValue(), string(buffer))
+		}
+	}
+}
+
+func TestSetMaskedForgetsEarlierCopies(t *testing.T) {
+	screen := NewVirtualScreen(80, 24)
+	CurrentBackend = screen
+	stack := NewDialogStack()
+	dialog := NewInputDialog("Title", "Password:", 20, "", nil, nil)
+	stack.Open(dialog)
+
+	// Typed before the dialog was masked, so both undo and the kill ring hold copies.
+	screen.QueueString("hunter2")
+	screen.QueueKey(termbox.KeyCtrlU)
+	screen.QueueString("hunter2")
+	stack.RunContext(context.Background())
+	if len(dialog.undo) == 0 || len(killRing) == 0 {
+		t.Fatalf("nothing recorded before masking (%d undo states, %d kill ring entries)", len(dialog.undo), len(killRing))
+	}
+	undone := dialog.undo[len(dialog.undo)-1].value
+	killed := killRing[len(killRing)-1]
+
+	dialog.SetMasked(true)
+	if len(dialog.undo) != 0 || len(killRing) != 0 {
+		t.Errorf("%d undo states and %d kill ring entries kept after masking", len(dialog.undo), len(killRing))
+	}
+	if strings.ContainsAny(string(undone)+string(killed), "hunter2") {
+		t.Errorf("old copies not wiped: undo %q, kill ring %q", string(undone), string(killed))
+	}
+}
+
+func TestInputDialogPaste(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   NewlinePolicy
+		text     string
+		value    string
+		rejected bool
+	}{
+		{"one line", StripNewlines, "abc", "xabc", false},
+		{"stripped", StripNewlines, "ab\ncd\r\nef", "xabcdef", false},
+		{"trailing newline", RejectNewlines, "abc\n", "xabc", false},
+		{"rejected", RejectNewlines, "ab\ncd", "x", true},
+	}
+
+	for _, test := range tests {
+		screen := NewVirtualScreen(80, 24)
+		CurrentBackend = screen
+
+		submitted := false
+		dialog := NewInputDialog("Title", "Value:", 20, "x", func(value string, arg interface{}) bool {
+			submitted = true
+			return true
+		}, nil)
+		dialog.SetNewlinePolicy(test.policy)
+		stack := NewDialogStack()
+		stack.Open(dialog)
+
+		screen.QueuePaste(test.text)
+		stack.RunContext(context.Background())
+
+		if submitted || !stack.IsOpen(dialog) {
+			t.Errorf("%s: pasting submitted the value", test.name)
+		}
+		if dialog.GetValue() != test.value {
+			t.Errorf("%s: value is %q after pasting %q, want %q", test.name, dialog.GetValue(), test.text, test.value)
+		}
+		if (dialog.invalid == ErrPasteNewlines) != test.rejected {
+			t.Errorf("%s: error is %v, want rejected %v", test.name, dialog.invalid, test.rejected)
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package termdialog
+
+import (
+	"errors"
+	"github.com/nsf/termbox-go"
+	"unicode"
+)
+
+// Type InputMask describes the layout of a structured value, such as a date or a phone number, for
+// an InputDialog. In the mask string, the following characters stand for slots that the user fills
+// in:
+//
+//	9  a digit
+//	#  an optional digit
+//	A  a letter
+//	N  a letter or digit
+//	*  any character
+//
+// Every other character is a fixed separator that is displayed in the field and skipped over by
+// the cursor; a backslash makes the following character a separator even if it would otherwise be
+// a slot. For example, "9999-99-99" describes an ISO date and "###.###.###.###" an IPv4 address.
+// A separator can be typed to skip the optional slots before it, so "10.0.0.1" can be entered
+// into the latter as it would be written.
+type InputMask struct {
+	mask  string
+	items []maskItem
+	slots int
+}
+
+// maskItem is one character of an input mask: either a slot or a separator.
+type maskItem struct {
+	class   rune // the mask character if this is a slot, or 0 if it is a separator
+	literal rune // the separator character
+}
+
+// Variable ErrIncomplete is the validation error shown when Enter is pressed before all the
+// required slots of an input mask have been filled in.
+var ErrIncomplete = errors.New("value is incomplete")
+
+// Function ParseInputMask parses a mask string, as described for InputMask.
+func ParseInputMask(mask string) (inputMask *InputMask, err error) {
+	inputMask = &InputMask{
+		mask: mask,
+	}
+
+	runes := []rune(mask)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; c {
+		case '9', '#', 'A', 'N', '*':
+			inputMask.items = append(inputMask.items, maskItem{class: c})
+			inputMask.slots++
+
+		case '\\':
+			i++
+			if i == len(runes) {
+				return nil, errors.New("termdialog: input mask ends with a backslash")
+			}
+			inputMask.items = append(inputMask.items, maskItem{literal: runes[i]})
+
+		default:
+			inputMask.items = append(inputMask.items, maskItem{literal: c})
+		}
+	}
+
+	if inputMask.slots == 0 {
+		return nil, errors.New("termdialog: input mask has no slots")
+	}
+
+	return inputMask, nil
+}
+
+// Function MustParseInputMask is like ParseInputMask, but panics if the mask is invalid. It is
+// intended for masks that are written into the program.
+func MustParseInputMask(mask string) (inputMask *InputMask) {
+	inputMask, err := ParseInputMask(mask)
+	if err != nil {
+		panic(err)
+	}
+	return inputMask
+}
+
+func (inputMask *InputMask) String() (mask string) {
+	return inputMask.mask
+}
+
+// accepts returns whether c may be typed into a slot of the given class.
+func (item maskItem) accepts(c rune) (ok bool) {
+	switch item.class {
+	case '9', '#':
+		return c >= '0' && c <= '9'
+	case 'A':
+		return unicode.IsLetter(c)
+	case 'N':
+		return unicode.IsLetter(c) || unicode.IsDigit(c)
+	case '*':
+		return unicode.IsPrint(c)
+	}
+	return false
+}
+
+// itemIndex returns the index into items of the given slot, or len(items) if slot is past the
+// last one.
+func (inputMask *InputMask) itemIndex(slot int) (index int) {
+	for index, item := range inputMask.items {
+		if item.class != 0 {
+			if slot == 0 {
+				return index
+			}
+			slot--
+		}
+	}
+	return len(inputMask.items)
+}
+
+// slotItem returns the mask item for the given slot.
+func (inputMask *InputMask) slotItem(slot int) (item maskItem) {
+	return inputMask.items[inputMask.itemIndex(slot)]
+}
+
+// format lays out the slots as they are displayed, with blank for empty slots. Empty optional slots
+// are left out of groups (runs of slots between separators) that have any slots filled in, so that
+// "10.0.0.1" is displayed as it would be written rather than with gaps. It also returns the offset
+// into display of each slot, and of the end of the mask, for placing the cursor.
+func (inputMask *InputMask) format(slots []rune, blank rune) (display []rune, offsets []int) {
+	// Number the groups of slots, and find which have any slots filled in.
+	groups := make([]int, len(slots))
+	filled := make(map[int]bool)
+	group, slot := 0, 0
+	for i, item := range inputMask.items {
+		if item.class == 0 {
+			if i > 0 && inputMask.items[i-1].class != 0 {
+				group++
+			}
+			continue
+		}
+
+		groups[slot] = group
+		if slots[slot] != 0 {
+			filled[group] = true
+		}
+		slot++
+	}
+
+	offsets = make([]int, 0, len(slots)+1)
+	slot = 0
+	for _, item := range inputMask.items {
+		if item.class == 0 {
+			display = append(display, item.literal)
+			continue
+		}
+
+		offsets = append(offsets, len(display))
+		if slots[slot] != 0 {
+			display = append(display, slots[slot])
+		} else if item.class != '#' || !filled[groups[slot]] {
+			display = append(display, blank)
+		}
+		slot++
+	}
+	offsets = append(offsets, len(display))
+
+	return display, offsets
+}
+
+// Function SetInputMask makes the dialog accept a structured value laid out by inputMask, or an
+// ordinary value again if it is nil. The existing value is re-entered into the mask as if it had
+// been typed. The value width is set to fit the mask, and history and completion are not used
+// while a mask is set. GetValue and the callback see the formatted value (with separators);
+// GetRawValue returns the characters that were entered, one for each slot.
+func (dialog *InputDialog) SetInputMask(inputMask *InputMask) {
+	value := dialog.GetValue()
+
+	dialog.inputMask = inputMask
+	dialog.metricsDirty = true
+	if inputMask == nil {
+		dialog.SetValue(value)
+		return
+	}
+
+	blank, _ := inputMask.format(make([]rune, inputMask.slots), '_')
+	dialog.valueWidth = stringWidth(string(blank)) + 1
+	dialog.SetValue(value)
+}
+
+func (dialog *InputDialog) GetInputMask() (inputMask *InputMask) {
+	return dialog.inputMask
+}
+
+// Function NewInputMaskDialog creates an input dialog for a structured value laid out by
+// inputMask. The callback is passed both the raw value (the characters that were entered, one for
+// each slot, as returned by GetRawValue) and the formatted one (with the separators).
+func NewInputMaskDialog(title string, prompt string, inputMask *InputMask, callback func(raw string, formatted string, arg interface{}) bool, arg interface{}) (dialog *InputDialog) {
+	dialog = NewInputDialog(title, prompt, 0, "", nil, arg)
+	dialog.SetInputMask(inputMask)
+
+	if callback != nil {
+		dialog.callback = func(formatted string, arg interface{}) bool {
+			return callback(dialog.GetRawValue(), formatted, arg)
+		}
+	}
+
+	return dialog
+}
+
+// Function GetRawValue returns the characters that have been entered into the slots of the input
+// mask, without the separators. There is one character for each slot, with a space for each empty
+// one, so that values with optional slots (such as "10.0.0.1" and "1.00.0.1" in "###.###.###.###")
+// remain distinct, and can be put back with SetRawValue. If there is no input mask, it is the same
+// as GetValue.
+func (dialog *InputDialog) GetRawValue() (raw string) {
+	if dialog.inputMask == nil {
+		return dialog.GetValue()
+	}
+
+	runes := make([]rune, len(dialog.slots))
+	for slot, c := range dialog.slots {
+		if c == 0 {
+			c = ' '
+		}
+		runes[slot] = c
+	}
+	return string(runes)
+}
+
+// Function SetRawValue sets the value from a raw value as returned by GetRawValue, putting each
+// character into its own slot of the input mask. Spaces, and characters that a slot does not
+// accept, leave the slot empty. If there is no input mask, it is the same as SetValue.
+func (dialog *InputDialog) SetRawValue(raw string) {
+	if dialog.inputMask == nil {
+		dialog.SetValue(raw)
+		return
+	}
+
+	dialog.clearUndo()
+	dialog.slots = make([]rune, dialog.inputMask.slots)
+	dialog.slot = 0
+	for slot, c := range []rune(raw) {
+		if slot == len(dialog.slots) {
+			break
+		}
+		if c != ' ' && dialog.inputMask.slotItem(slot).accepts(c) {
+			dialog.slots[slot] = c
+			dialog.slot = slot + 1
+		}
+	}
+	dialog.renderMask()
+}
+
+// formatted returns the value with its separators. Empty optional slots are left out, as are the
+// separators after the last filled slot unless every slot up to the end of the mask is filled.
+func (dialog *InputDialog) formatted() (formatted string) {
+	inputMask := dialog.inputMask
+
+	last := -1
+	for slot, c := range dialog.slots {
+		if c != 0 {
+			last = slot
+		}
+	}
+	if last < 0 {
+		return ""
+	}
+
+	end := inputMask.itemIndex(last) + 1
+	if last == inputMask.slots-1 {
+		end = len(inputMask.items)
+	}
+
+	runes := make([]rune, 0, end)
+	slot := 0
+	for _, item := range inputMask.items[:end] {
+		if item.class == 0 {
+			runes = append(runes, item.literal)
+		} else {
+			if dialog.slots[slot] != 0 {
+				runes = append(runes, dialog.slots[slot])
+			}
+			slot++
+		}
+	}
+	return string(runes)
+}
+
+// maskComplete returns whether every required slot has been filled.
+func (dialog *InputDialog) maskComplete() (complete bool) {
+	for slot, c := range dialog.slots {
+		if c == 0 && dialog.inputMask.slotItem(slot).class != '#' {
+			return false
+		}
+	}
+	return true
+}
+
+// renderMask updates the displayed value and cursor from the slots.
+func (dialog *InputDialog) renderMask() {
+	display, offsets := dialog.inputMask.format(dialog.slots, '_')
+	dialog.value = display
+	dialog.cursor = offsets[dialog.slot]
+}
+
+// setMaskValue clears the slots and types value into them.
+func (dialog *InputDialog) setMaskValue(value string) {
+	dialog.slots = make([]rune, dialog.inputMask.slots)
+	dialog.slot = 0
+	for _, c := range value {
+		dialog.typeMask(c)
+	}
+	dialog.renderMask()
+}
+
+// typeMask enters c at the cursor, if the slot there accepts it. Otherwise, if c is the next
+// separator and only optional slots come before it, the cursor skips to the slot after it.
+func (dialog *InputDialog) typeMask(c rune) (ok bool) {
+	inputMask := dialog.inputMask
+
+	if dialog.slot < inputMask.slots && inputMask.slotItem(dialog.slot).accepts(c) {
+		dialog.slots[dialog.slot] = c
+		dialog.slot++
+		return true
+	}
+
+	// Typing the separator that the cursor has just passed is harmless.
+	index := inputMask.itemIndex(dialog.slot)
+	if index > 0 && inputMask.items[index-1].class == 0 && inputMask.items[index-1].literal == c {
+		return true
+	}
+
+	slot := dialog.slot
+	for _, item := range inputMask.items[index:] {
+		if item.class == 0 {
+			if item.literal == c {
+				for ; dialog.slot < slot; dialog.slot++ {
+					dialog.slots[dialog.slot] = 0
+				}
+				return true
+			}
+		} else if item.class == '#' {
+			slot++
+		} else {
+			break
+		}
+	}
+
+	return false
+}
+
+// handleMaskKey handles a keypress when there is an input mask. The value can only be typed into
+// and deleted from; the cursor moves from slot to slot, skipping the separators.
+func (dialog *InputDialog) handleMaskKey(event termbox.Event) (handled bool, shouldClose bool) {
+	if event.Ch != 0 {
+		if event.Mod&termbox.ModAlt != 0 {
+			return false, false
+		}
+		dialog.typeMask(event.Ch)
+	} else {
+		switch event.Key {
+		case termbox.KeyEnter:
+			return true, dialog.submit()
+
+		case termbox.KeySpace:
+			dialog.typeMask(' ')
+
+		case termbox.KeyBackspace, termbox.KeyBackspace2:
+			// Step back over any optional slots that were skipped.
+			for dialog.slot > 0 {
+				dialog.slot--
+				if dialog.slots[dialog.slot] != 0 {
+					dialog.slots[dialog.slot] = 0
+					break
+				}
+			}
+
+		case termbox.KeyDelete, termbox.KeyCtrlD:
+			if dialog.slot < len(dialog.slots) {
+				dialog.slots[dialog.slot] = 0
+			}
+
+		case termbox.KeyArrowLeft, termbox.KeyCtrlB:
+			if dialog.slot > 0 {
+				dialog.slot--
+			}
+
+		case termbox.KeyArrowRight, termbox.KeyCtrlF:
+			if dialog.slot < len(dialog.slots) {
+				dialog.slot++
+			}
+
+		case termbox.KeyHome, termbox.KeyCtrlA:
+			dialog.slot = 0
+
+		case termbox.KeyEnd, termbox.KeyCtrlE:
+			dialog.slot = 0
+			for slot, c := range dialog.slots {
+				if c != 0 {
+					dialog.slot = slot + 1
+				}
+			}
+
+		case termbox.KeyCtrlU:
+			dialog.setMaskValue("")
+
+		default:
+			return false, false
+		}
+	}
+
+	dialog.renderMask()
+	dialog.revalidate()
+	return true, false
+}
@@ -0,0 +1,180 @@
+package termdialog
+
+import (
+	"context"
+	"github.com/nsf/termbox-go"
+	"testing"
+)
+
+func TestParseInputMask(t *testing.T) {
+	tests := []struct {
+		mask  string
+		slots int
+		blank string // the field as displayed with nothing entered
+	}{
+		{"9999-99-99", 8, "____-__-__"},
+		{"###.###.###.###", 12, "___.___.___.___"},
+		{"(999) 999", 6, "(___) ___"},
+		{"AA-NN-**", 6, "__-__-__"},
+		{`\99`, 1, "9_"},
+		{`9\\9`, 2, `_\_`},
+	}
+
+	for _, test := range tests {
+		inputMask, err := ParseInputMask(test.mask)
+		if err != nil {
+			t.Errorf("ParseInputMask(%q) returned %v", test.mask, err)
+			continue
+		}
+
+		blank, offsets := inputMask.format(make([]rune, inputMask.slots), '_')
+		if inputMask.slots != test.slots || string(blank) != test.blank {
+			t.Errorf("ParseInputMask(%q) has %d slots, displayed as %q; want %d, %q", test.mask, inputMask.slots, string(blank), test.slots, test.blank)
+		}
+		if len(offsets) != test.slots+1 || offsets[test.slots] != len(blank) {
+			t.Errorf("ParseInputMask(%q) has slot offsets %v, want %d ending with %d", test.mask, offsets, test.slots+1, len(blank))
+		}
+	}
+
+	for _, mask := range []string{"", "-/-", `99\`, `\9`} {
+		_, err := ParseInputMask(mask)
+		if err == nil {
+			t.Errorf("ParseInputMask(%q) succeeded, want an error", mask)
+		}
+	}
+}
+
+// newMaskDialog opens an input dialog with the given mask on a new virtual screen.
+func newMaskDialog(mask string) (dialog *InputDialog, stack *DialogStack, screen *VirtualScreen) {
+	screen = NewVirtualScreen(80, 24)
+	CurrentBackend = screen
+
+	dialog = NewInputMaskDialog("Title", "Value:", MustParseInputMask(mask), nil, nil)
+	stack = NewDialogStack()
+	stack.Open(dialog)
+	return dialog, stack, screen
+}
+
+func TestInputMaskTyping(t *testing.T) {
+	tests := []struct {
+		mask    string
+		typed   string
+		display string
+		cursor  int
+		value   string
+		raw     string
+	}{
+		{"9999-99-99", "20240131", "2024-01-31", 10, "2024-01-31", "20240131"},
+		{"9999-99-99", "2024-01", "2024-01-__", 8, "2024-01", "202401  "},
+		{"9999-99-99", "2024x01", "2024-01-__", 8, "2024-01", "202401  "},
+		{"###.###.###.###", "10.0.0.1", "10.0.0.1", 8, "10.0.0.1", "10 0  0  1  "},
+		{"###.###.###.###", "1.00.0.1", "1.00.0.1", 8, "1.00.0.1", "1  00 0  1  "},
+		{"###.###.###.###", "10", "10.___.___.___", 2, "10", "10          "},
+		{"###.###.###.###", "10.", "10.___.___.___", 3, "10", "10          "},
+		{"###.###", "..", "___.___", 4, "", "      "},
+		{"(999) 999", "(555) 123", "(555) 123", 9, "(555) 123", "555123"},
+		{"AA-99", "1ab12", "ab-12", 5, "ab-12", "ab12"},
+	}
+
+	for _, test := range tests {
+		dialog, stack, screen := newMaskDialog(test.mask)
+		screen.QueueString(test.typed)
+		stack.RunContext(context.Background())
+
+		if string(dialog.value) != test.display || dialog.GetCursor() != test.cursor {
+			t.Errorf("%q typed into %q displays %q with the cursor at %d, want %q at %d", test.typed, test.mask, string(dialog.value), dialog.GetCursor(), test.display, test.cursor)
+		}
+		if dialog.GetValue() != test.value || dialog.GetRawValue() != test.raw {
+			t.Errorf("%q typed into %q gives value %q and raw value %q, want %q and %q", test.typed, test.mask, dialog.GetValue(), dialog.GetRawValue(), test.value, test.raw)
+		}
+	}
+}
+
+func TestInputMaskBackspace(t *testing.T) {
+	tests := []struct {
+		mask    string
+		typed   string
+		steps   []string // the displayed value after each Backspace
+		cursors []int
+	}{
+		// Backspace steps back over the separators.
+		{"9999-99-99", "202401", []string{"2024-0_-__", "2024-__-__", "202_-__-__"}, []int{6, 5, 3}},
+		// And over optional slots that were skipped, to the last character entered.
+		{"###.###.###.###", "10.5", []string{"10.___.___.___", "1.___.___.___", "___.___.___.___"}, []int{3, 1, 0}},
+	}
+
+	for _, test := range tests {
+		dialog, stack, screen := newMaskDialog(test.mask)
+		screen.QueueString(test.typed)
+		stack.RunContext(context.Background())
+
+		for i, want := range test.steps {
+			screen.QueueKey(termbox.KeyBackspace2)
+			stack.RunContext(context.Background())
+			if string(dialog.value) != want || dialog.GetCursor() != test.cursors[i] {
+				t.Errorf("%q in %q after %d Backspaces displays %q with the cursor at %d, want %q at %d", test.typed, test.mask, i+1, string(dialog.value), dialog.GetCursor(), want, test.cursors[i])
+			}
+		}
+	}
+}
+
+func TestInputMaskRawValueRoundTrip(t *testing.T) {
+	tests := []struct {
+		mask  string
+		typed string
+	}{
+		{"###.###.###.###", "10.0.0.1"},
+		{"###.###.###.###", "1.00.0.1"},
+		{"###.###.###.###", "192.168.100.200"},
+		{"9999-99-99", "20240131"},
+		{"AA-99", "ab12"},
+	}
+
+	for _, test := range tests {
+		dialog, stack, screen := newMaskDialog(test.mask)
+		screen.QueueString(test.typed)
+		stack.RunContext(context.Background())
+
+		restored, _, _ := newMaskDialog(test.mask)
+		restored.SetRawValue(dialog.GetRawValue())
+		if restored.GetValue() != dialog.GetValue() || string(restored.value) != string(dialog.value) {
+			t.Errorf("raw value %q of %q restored as %q (displayed %q), want %q (displayed %q)", dialog.GetRawValue(), test.typed, restored.GetValue(), string(restored.value), dialog.GetValue(), string(dialog.value))
+		}
+	}
+}
+
+func TestInputMaskDialogCallback(t *testing.T) {
+	screen := NewVirtualScreen(80, 24)
+	CurrentBackend = screen
+
+	var raw, formatted string
+	dialog := NewInputMaskDialog("IP", "Address:", MustParseInputMask("###.###.###.###"), func(r string, f string, arg interface{}) bool {
+		raw, formatted = r, f
+		return true
+	}, nil)
+	stack := NewDialogStack()
+	stack.Open(dialog)
+
+	screen.QueueString("10.0.0.1")
+	screen.QueueKey(termbox.KeyEnter)
+	stack.RunContext(context.Background())
+	if raw != "10 0  0  1  " || formatted != "10.0.0.1" {
+		t.Errorf("callback got raw %q and formatted %q, want %q and %q", raw, formatted, "10 0  0  1  ", "10.0.0.1")
+	}
+}
+
+func TestInputMaskIncompleteNotSubmitted(t *testing.T) {
+	dialog, stack, screen := newMaskDialog("9999-99-99")
+	submitted := false
+	dialog.SetCallback(func(value string, arg interface{}) bool {
+		submitted = true
+		return true
+	})
+
+	screen.QueueString("2024-01")
+	screen.QueueKey(termbox.KeyEnter)
+	stack.RunContext(context.Background())
+	if submitted || !stack.IsOpen(dialog) || dialog.invalid != ErrIncomplete {
+		t.Errorf("incomplete value submitted %v (open %v, error %v), want it kept open with ErrIncomplete", submitted, stack.IsOpen(dialog), dialog.invalid)
+	}
+}
@@ -0,0 +1,156 @@
+package termdialog
+
+import (
+	"unicode"
+)
+
+// command identifies what the last key handled by an input dialog did, for keys whose effect
+// depends on it (e.g. Alt+Y only works straight after Ctrl+Y, and consecutive kills are joined).
+type command int
+
+const (
+	commandOther command = iota
+	commandInsert
+	commandKill
+	commandYank
+	commandComplete
+)
+
+// inputState is a snapshot of an input dialog's value, saved so that an edit can be undone.
+type inputState struct {
+	value  []rune
+	cursor int
+}
+
+// maxKillRing is the number of killed pieces of text that are remembered for Ctrl+Y and Alt+Y.
+const maxKillRing = 16
+
+// maxUndo is the number of edits to an input dialog that can be undone.
+const maxUndo = 100
+
+// killRing holds text deleted with Ctrl+K, Ctrl+U, Ctrl+W and friends, newest last. As in readline,
+// it is shared by all input dialogs. Text killed in masked mode is never added to it.
+var killRing [][]rune
+
+// saveUndo records the value before an edit so that Ctrl+_ can restore it. Nothing is recorded in
+// masked mode, so that no copies of the value are kept.
+func (dialog *InputDialog) saveUndo() {
+	if dialog.masked {
+		return
+	}
+
+	state := inputState{
+		value:  append([]rune(nil), dialog.value...),
+		cursor: dialog.cursor,
+	}
+
+	if len(dialog.undo) == maxUndo {
+		wipeRunes(dialog.undo[0].value)
+		dialog.undo = dialog.undo[1:]
+	}
+	dialog.undo = append(dialog.undo, state)
+}
+
+// undoEdit restores the value as it was before the last edit.
+func (dialog *InputDialog) undoEdit() {
+	n := len(dialog.undo)
+	if n == 0 {
+		return
+	}
+
+	wipeRunes(dialog.value)
+	dialog.value = dialog.undo[n-1].value
+	dialog.cursor = dialog.undo[n-1].cursor
+	dialog.undo = dialog.undo[:n-1]
+}
+
+// clearUndo forgets (and wipes) all the saved states.
+func (dialog *InputDialog) clearUndo() {
+	for _, state := range dialog.undo {
+		wipeRunes(state.value)
+	}
+	dialog.undo = nil
+}
+
+// clearKillRing forgets (and wipes) all the killed text. As the kill ring is shared, this affects
+// all input dialogs.
+func clearKillRing() {
+	for _, killed := range killRing {
+		wipeRunes(killed)
+	}
+	killRing = nil
+}
+
+// kill deletes the runes between from and to, adding them to the kill ring. If the previous command
+// was also a kill, the text is joined onto the last entry instead, so that it can be yanked back
+// in one piece.
+func (dialog *InputDialog) kill(from int, to int, previous command) {
+	dialog.lastCommand = commandKill
+	if from == to {
+		return
+	}
+
+	if !dialog.masked {
+		killed := append([]rune(nil), dialog.value[from:to]...)
+		last := len(killRing) - 1
+
+		if previous == commandKill && last >= 0 {
+			if from < dialog.cursor {
+				killRing[last] = append(killed, killRing[last]...)
+			} else {
+				killRing[last] = append(killRing[last], killed...)
+			}
+		} else {
+			if len(killRing) == maxKillRing {
+				killRing = killRing[1:]
+			}
+			killRing = append(killRing, killed)
+		}
+	}
+
+	dialog.saveUndo()
+	dialog.remove(from, to)
+}
+
+// yank inserts the most recently killed text at the cursor.
+func (dialog *InputDialog) yank() {
+	if len(killRing) == 0 {
+		return
+	}
+
+	dialog.saveUndo()
+	dialog.yankIndex = len(killRing) - 1
+	dialog.yankStart = dialog.cursor
+	dialog.insert(string(killRing[dialog.yankIndex]))
+	dialog.lastCommand = commandYank
+}
+
+// yankPop replaces the text just yanked with the kill ring entry before it. It does nothing unless
+// the previous command was a yank.
+func (dialog *InputDialog) yankPop(previous command) {
+	if previous != commandYank || len(killRing) == 0 {
+		return
+	}
+
+	dialog.remove(dialog.yankStart, dialog.cursor)
+	dialog.yankIndex = (dialog.yankIndex + len(killRing) - 1) % len(killRing)
+	dialog.insert(string(killRing[dialog.yankIndex]))
+	dialog.lastCommand = commandYank
+}
+
+// spaceWordLeft returns the position of the start of the whitespace-delimited word before the
+// cursor, as used by Ctrl+W.
+func (dialog *InputDialog) spaceWordLeft() (pos int) {
+	if dialog.hidden() {
+		return 0
+	}
+
+	pos = dialog.cursor
+	for pos > 0 && unicode.IsSpace(dialog.value[pos-1]) {
+		pos--
+	}
+	for pos > 0 && !unicode.IsSpace(dialog.value[pos-1]) {
+		pos--
+	}
+	return snapToBoundary(graphemeBoundaries(dialog.value), pos)
+}
@@ -18,6 +18,7 @@ import (
 type MessageDialog struct {
 	BaseDialog
 	message string
+	lines   []string // the message, wrapped to fit on the screen
 }
 
 func NewMessageDialog(title string, message string) (dialog *MessageDialog) {
@@ -40,31 +41,58 @@ func (dialog *MessageDialog) SetMessage(message string) {
 	dialog.metricsDirty = true
 }
 
+// Function CalcMetrics sizes the dialog to fit the message, wrapping any lines that are too wide
+// for the screen.
 func (dialog *MessageDialog) CalcMetrics() {
-	windowWidth, windowHeight := termbox.Size()
+	windowWidth, _ := CurrentBackend.Size()
+	dialog.lines = wrapMessage(dialog.message, windowWidth-6)
 
 	maxWidth := len(dialog.BaseDialog.title)
-	lines := strings.Split(dialog.message, "\n")
-
-	for _, line := range lines {
-		if len(line) > maxWidth {
-			maxWidth = len(line)
+	for _, line := range dialog.lines {
+		if stringWidth(line) > maxWidth {
+			maxWidth = stringWidth(line)
 		}
 	}
 
 	dialog.width = 6 + maxWidth // 6 = "|  " + "  |"
-	dialog.height = 6 + len(lines)
+	dialog.height = 6 + len(dialog.lines)
 
-	dialog.x = (windowWidth / 2) - (dialog.width / 2)
-	dialog.y = (windowHeight / 2) - (dialog.height / 2)
+	dialog.fitToScreen(6+minContentWidth, 7)
 
 	dialog.metricsDirty = false
 }
 
 func (dialog *MessageDialog) Open() {
-	BaseDialogOpen(dialog)
+	if !BaseDialogOpen(dialog) {
+		return
+	}
+
+	for i, line := range dialog.lines {
+		dialog.drawContent(dialog.x+3, dialog.y+4+i, line, dialog.theme.InactiveItem)
+	}
+}
 
-	DrawString(dialog.x+3, dialog.y+4, dialog.message, dialog.theme.InactiveItem)
+// wrapMessage splits a message into lines at line breaks ("\n" or "\r\n"), and wraps lines that
+// are wider than width between words. The continuation lines of bullet points (lines starting
+// with "* ") are indented to line up with the text of the first.
+func wrapMessage(message string, width int) (lines []string) {
+	for _, line := range strings.Split(message, "\n") {
+		runes := []rune(strings.TrimSuffix(line, "\r"))
+
+		indent := ""
+		if strings.HasPrefix(string(runes), "* ") && width > 2+minContentWidth {
+			indent = "  "
+		}
+
+		for i, wrapped := range wrapParagraph(runes, 0, len(runes), width-len(indent)) {
+			text := strings.TrimRight(string(runes[wrapped.start:wrapped.end]), " ")
+			if i > 0 {
+				text = indent + text
+			}
+			lines = append(lines, text)
+		}
+	}
+	return lines
 }
 
 func (dialog *MessageDialog) HandleEvent(event termbox.Event) (handled bool, shouldClose bool) {
@@ -77,6 +105,7 @@ func (dialog *MessageDialog) HandleEvent(event termbox.Event) (handled bool, sho
 	case termbox.EventKey:
 		switch event.Key {
 		case termbox.KeyEnter, termbox.KeySpace:
+			dialog.result = Confirmed
 			return true, true
 		}
 	}
@@ -0,0 +1,56 @@
+package termdialog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMessageDialogWrapsLongLines(t *testing.T) {
+	screen := NewVirtualScreen(30, 24)
+	CurrentBackend = screen
+
+	message := "Short line.\r\n* A bullet point that is much too long to fit on one line of the screen."
+	stack := NewDialogStack()
+	dialog := NewMessageDialog("Title", message)
+	stack.Open(dialog)
+	stack.Draw()
+
+	want := []string{
+		"Short line.",
+		"* A bullet point that",
+		"  is much too long to",
+		"  fit on one line of the",
+		"  screen.",
+	}
+	for i, line := range want {
+		row := []rune(screen.Line(dialog.GetY() + 4 + i))
+		got := strings.TrimRight(string(row[dialog.GetX()+3:dialog.GetX()+dialog.GetWidth()-1]), " ")
+		if got != line {
+			t.Errorf("line %d is %q, want %q", i, got, line)
+		}
+	}
+	if dialog.GetWidth() > 30 {
+		t.Errorf("dialog is %d cells wide, wider than the screen", dialog.GetWidth())
+	}
+}
+
+func TestWrapMessage(t *testing.T) {
+	tests := []struct {
+		message string
+		width   int
+		want    []string
+	}{
+		{"one two three", 20, []string{"one two three"}},
+		{"one two three", 8, []string{"one two", "three"}},
+		{"one\r\ntwo\n\nthree", 20, []string{"one", "two", "", "three"}},
+		{"* one two three", 9, []string{"* one", "  two", "  three"}},
+		{"* one two", 4, []string{"*", "one", "two"}}, // too narrow to indent
+	}
+
+	for _, test := range tests {
+		got := wrapMessage(test.message, test.width)
+		if strings.Join(got, "|") != strings.Join(test.want, "|") {
+			t.Errorf("wrapMessage(%q, %d) = %q, want %q", test.message, test.width, got, test.want)
+		}
+	}
+}
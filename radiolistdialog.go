@@ -0,0 +1,114 @@
+package termdialog
+
+import (
+	"github.com/nsf/termbox-go"
+)
+
+/*
+  +-------------+
+  |             |
+  |  Title      |
+  |             |
+  |  ( ) xxx    |
+  |  (*) yyy    |
+  |  ( ) zzz    |
+  |             |
+  +-------------+
+*/
+
+// Type RadioListDialog represents a dialog in which exactly one option is marked, such as the
+// current value of a setting. The marked option is selected when the dialog is created, Space
+// moves the mark to the selected option, and Enter submits the marked option (which need not be
+// the selected one). The options' own callbacks are not called.
+type RadioListDialog struct {
+	SelectionDialog
+	marked   *Option
+	callback func(*Option, interface{}) bool
+	arg      interface{}
+}
+
+// Function NewRadioListDialog creates and returns a new radio list dialog with the given option
+// marked and selected. If marked is nil, or is not one of the options, the first option is marked.
+// The callback is called with the marked option when Enter is pressed; if it returns false, the
+// dialog stays open. The callback can be nil. Set maxVisibleOptions to get scrolling, as for
+// NewSelectionDialog.
+func NewRadioListDialog(title string, options []*Option, marked *Option, callback func(marked *Option, arg interface{}) bool, arg interface{}, maxVisibleOptions ...int) (dialog *RadioListDialog) {
+	dialog = &RadioListDialog{
+		SelectionDialog: *NewSelectionDialog(title, options, maxVisibleOptions...),
+		callback:        callback,
+		arg:             arg,
+	}
+
+	dialog.itemPrefix = dialog.radioButton
+	dialog.SetMarkedOption(marked)
+	return dialog
+}
+
+func (dialog *RadioListDialog) radioButton(option *Option) (prefix string) {
+	if option == dialog.GetMarkedOption() {
+		return "(*) "
+	}
+	return "( ) "
+}
+
+// Function GetMarkedOption returns the marked option, or nil if the dialog has no options.
+func (dialog *RadioListDialog) GetMarkedOption() (option *Option) {
+	if dialog.FindOption(dialog.marked) >= 0 {
+		return dialog.marked
+	}
+	if len(dialog.options) > 0 {
+		return dialog.options[0]
+	}
+	return nil
+}
+
+// Function SetMarkedOption marks the given option and selects it. If it is nil, or is not one of
+// the options, the first option is marked instead.
+func (dialog *RadioListDialog) SetMarkedOption(option *Option) {
+	dialog.marked = option
+	if index := dialog.FindOption(dialog.GetMarkedOption()); index >= 0 {
+		dialog.SetSelectedIndex(index)
+	}
+}
+
+func (dialog *RadioListDialog) GetCallback() (callback func(*Option, interface{}) bool) {
+	return dialog.callback
+}
+
+func (dialog *RadioListDialog) SetCallback(callback func(*Option, interface{}) bool) {
+	dialog.callback = callback
+}
+
+func (dialog *RadioListDialog) GetCallbackArg() (arg interface{}) {
+	return dialog.arg
+}
+
+func (dialog *RadioListDialog) SetCallbackArg(arg interface{}) {
+	dialog.arg = arg
+}
+
+func (dialog *RadioListDialog) HandleEvent(event termbox.Event) (handled bool, shouldClose bool) {
+	if event.Type == termbox.EventKey && event.Ch == 0 {
+		switch event.Key {
+		case termbox.KeySpace:
+			if dialog.shown() > 0 {
+				dialog.marked = dialog.GetSelectedOption()
+			}
+			return true, false
+
+		case termbox.KeyEnter:
+			marked := dialog.GetMarkedOption()
+			if marked == nil {
+				return true, false
+			}
+
+			shouldClose = dialog.callback == nil || dialog.callback(marked, dialog.arg)
+			if shouldClose {
+				dialog.result = Confirmed
+			}
+			return true, shouldClose
+		}
+	}
+
+	return dialog.SelectionDialog.HandleEvent(event)
+}
@@ -0,0 +1,21 @@
+package termdialog
+
+// Type Result describes how a dialog was closed.
+type Result int
+
+const (
+	Pending   Result = iota // The dialog has not been closed yet.
+	Confirmed               // The user accepted the dialog (e.g. by pressing Enter).
+	Cancelled               // The user dismissed the dialog with the escape key.
+	Aborted                 // The dialog was closed by the application, e.g. by stopping the dialog stack.
+	TimedOut                // The dialog was closed because a deadline expired.
+)
+
+var resultNames = []string{"Pending", "Confirmed", "Cancelled", "Aborted", "TimedOut"}
+
+func (result Result) String() (name string) {
+	if result >= 0 && int(result) < len(resultNames) {
+		return resultNames[result]
+	}
+	return "Result(?)"
+}
@@ -1,8 +1,10 @@
 package termdialog
 
 import (
-	"fmt"
 	"github.com/nsf/termbox-go"
+	"github.com/rivo/uniseg"
+	"sort"
+	"unicode"
 )
 
 /*
@@ -24,13 +26,31 @@ type Option struct {
 	Data     interface{}        // Arbitary associated data that can be accessed by the callback.
 }
 
+// Type FilterMode says how typing into a selection dialog narrows down the options shown. Matching
+// ignores case.
+type FilterMode int
+
+const (
+	NoFilter        FilterMode = iota // Typed characters are ignored.
+	SubstringFilter                   // Options containing the typed text are shown.
+	FuzzyFilter                       // Options containing the typed characters in order, though not necessarily together, are shown, best matches first.
+)
+
 // Type SelectionDialog represents a dialog with a number of selectable options.
 type SelectionDialog struct {
 	BaseDialog
 	options           []*Option
 	selectedIndex     int
 	maxVisibleOptions int // if > 0, scrolling activated, window height limited to the value
-	topIndex          int
+	topIndex          int // the first visible position in the list of options shown
+	filterMode        FilterMode
+	query             []rune
+	view              []int   // the indices of the options that match the query, or nil if there is no query
+	highlights        [][]int // for each entry in view, the offsets of the runes of the option's text that matched
+
+	// itemPrefix, if set, returns what to draw before an option in place of the usual "* ". It is
+	// used by dialogs built on this one, such as ChecklistDialog.
+	itemPrefix func(option *Option) (prefix string)
 }
 
 // Function NewSelectionDialog creates and returns a new selection dialog. The options argument can
@@ -67,17 +87,20 @@ func (dialog *SelectionDialog) GetOption(n int) (option *Option) {
 func (dialog *SelectionDialog) SetOption(n int, option *Option) {
 	dialog.options[n] = option
 	dialog.metricsDirty = true
+	dialog.refilter()
 }
 
 func (dialog *SelectionDialog) AddOption(option *Option) (theSameOption *Option) {
 	dialog.options = append(dialog.options, option)
 	dialog.metricsDirty = true
+	dialog.refilter()
 	return option
 }
 
 func (dialog *SelectionDialog) RemoveOption(n int) {
 	dialog.options = append(dialog.options[:n], dialog.options[n+1:]...)
 	dialog.metricsDirty = true
+	dialog.refilter()
 }
 
 func (dialog *SelectionDialog) FindOption(option *Option) (n int) {
@@ -94,6 +117,123 @@ func (dialog *SelectionDialog) ClearOptions() {
 	dialog.topIndex = 0
 	dialog.selectedIndex = 0
 	dialog.metricsDirty = true
+	dialog.refilter()
+}
+
+func (dialog *SelectionDialog) GetFilterMode() (filterMode FilterMode) {
+	return dialog.filterMode
+}
+
+// Function SetFilterMode sets whether, and how, typing narrows down the options shown. Space still
+// chooses the selected option, so it cannot be typed into the filter; Backspace removes the last
+// character of the filter and Esc clears it (and only closes the dialog if it is already empty).
+// The filter is also cleared when the dialog closes, so it starts afresh if it is opened again.
+func (dialog *SelectionDialog) SetFilterMode(filterMode FilterMode) {
+	dialog.filterMode = filterMode
+	dialog.refilter()
+}
+
+// Function GetFilter returns the text typed to filter the options.
+func (dialog *SelectionDialog) GetFilter() (query string) {
+	return string(dialog.query)
+}
+
+// Function SetFilter sets the text used to filter the options, as if it had been typed. With
+// FuzzyFilter, the best match is selected.
+func (dialog *SelectionDialog) SetFilter(query string) {
+	dialog.query = []rune(query)
+	dialog.refilter()
+
+	if dialog.filterMode == FuzzyFilter && len(dialog.view) > 0 {
+		dialog.selectedIndex = dialog.view[0]
+	}
+}
+
+// refilter works out which options match the query, and which characters of them matched. With
+// FuzzyFilter, the matches are ranked by score, then by length, so that the closest come first. If
+// the selected option no longer matches, the first one that does is selected instead.
+func (dialog *SelectionDialog) refilter() {
+	if dialog.filterMode == NoFilter || len(dialog.query) == 0 {
+		dialog.view = nil
+		dialog.highlights = nil
+		return
+	}
+
+	type match struct {
+		index     int
+		score     int
+		length    int
+		positions []int
+	}
+
+	query := lowerRunes(dialog.query)
+	matches := make([]match, 0, len(dialog.options))
+	for i, option := range dialog.options {
+		text := []rune(option.Text)
+
+		var score int
+		var positions []int
+		var ok bool
+		if dialog.filterMode == SubstringFilter {
+			positions, ok = substringMatch(text, query)
+		} else {
+			score, positions, ok = fuzzyMatch(text, query)
+		}
+
+		if ok {
+			matches = append(matches, match{i, score, len(text), positions})
+		}
+	}
+
+	if dialog.filterMode == FuzzyFilter {
+		sort.SliceStable(matches, func(a, b int) bool {
+			if matches[a].score != matches[b].score {
+				return matches[a].score > matches[b].score
+			}
+			return matches[a].length < matches[b].length
+		})
+	}
+
+	dialog.view = make([]int, len(matches))
+	dialog.highlights = make([][]int, len(matches))
+	for pos, m := range matches {
+		dialog.view[pos] = m.index
+		dialog.highlights[pos] = m.positions
+	}
+
+	if len(dialog.view) > 0 && dialog.positionOf(dialog.selectedIndex) < 0 {
+		dialog.selectedIndex = dialog.view[0]
+	}
+}
+
+// shown returns the number of options that match the query.
+func (dialog *SelectionDialog) shown() (n int) {
+	if dialog.view != nil {
+		return len(dialog.view)
+	}
+	return len(dialog.options)
+}
+
+// optionAt returns the index of the option at the given position in the list of options shown.
+func (dialog *SelectionDialog) optionAt(pos int) (index int) {
+	if dialog.view != nil {
+		return dialog.view[pos]
+	}
+	return pos
+}
+
+// positionOf returns the position of an option in the list of options shown, or -1 if it does not
+// match the query.
+func (dialog *SelectionDialog) positionOf(index int) (pos int) {
+	if dialog.view == nil {
+		return index
+	}
+	for pos, i := range dialog.view {
+		if i == index {
+			return pos
+		}
+	}
+	return -1
 }
 
 func (dialog *SelectionDialog) GetSelectedIndex() (selectedIndex int) {
@@ -115,22 +255,20 @@ func (dialog *SelectionDialog) SetSelectionOption(option *Option) {
 }
 
 func (dialog *SelectionDialog) CalcMetrics() {
-	windowWidth, windowHeight := termbox.Size()
-
 	maxWidth := 0
 	for _, option := range dialog.options {
-		if len(option.Text) > maxWidth {
-			maxWidth = len(option.Text)
+		width := len(dialog.optionPrefix(option)) + len(option.Text) // Include the "* "
+		if width > maxWidth {
+			maxWidth = width
 		}
 	}
 
-	maxWidth += 2 // Add the "* "
 	if len(dialog.title) > maxWidth {
 		maxWidth = len(dialog.title)
 	}
 
 	dialog.width = 6 + maxWidth // 6 = "|  " + "  |"
-	dialog.height += 6          // 6 = Top border, Top padding, Title, Under-title padding, Bottom padding, Bottom border
+	dialog.height = 6           // 6 = Top border, Top padding, Title, Under-title padding, Bottom padding, Bottom border
 
 	if dialog.maxVisibleOptions > 0 {
 		dialog.height += dialog.maxVisibleOptions
@@ -138,12 +276,19 @@ func (dialog *SelectionDialog) CalcMetrics() {
 		dialog.height += len(dialog.options)
 	}
 
-	dialog.x = (windowWidth / 2) - (dialog.width / 2)
-	dialog.y = (windowHeight / 2) - (dialog.height / 2)
+	dialog.fitToScreen(6+minContentWidth, 7)
 
 	dialog.metricsDirty = false
 }
 
+// optionPrefix returns what is drawn before an option.
+func (dialog *SelectionDialog) optionPrefix(option *Option) (prefix string) {
+	if dialog.itemPrefix != nil {
+		return dialog.itemPrefix(option)
+	}
+	return "* "
+}
+
 func min(x, y int) int {
 	if x < y {
 		return x
@@ -151,75 +296,180 @@ func min(x, y int) int {
 	return y
 }
 
-func (dialog *SelectionDialog) Open() {
-	BaseDialogOpen(dialog)
+func max(x, y int) int {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+// visibleOptions returns the number of options that fit in the dialog at once.
+func (dialog *SelectionDialog) visibleOptions() (n int) {
+	n = dialog.height - 6
+	if dialog.maxVisibleOptions > 0 {
+		n = min(n, dialog.maxVisibleOptions)
+	}
+	return min(n, dialog.shown())
+}
+
+// scrollToSelection adjusts topIndex so that the selected option is among the given number of
+// visible options.
+func (dialog *SelectionDialog) scrollToSelection(visible int) {
+	selected := dialog.positionOf(dialog.selectedIndex)
+	if selected < 0 {
+		selected = 0
+	}
 
-	if len(dialog.options) <= dialog.maxVisibleOptions {
+	if selected < dialog.topIndex {
+		dialog.topIndex = selected
+	}
+	if selected >= dialog.topIndex+visible {
+		dialog.topIndex = selected - visible + 1
+	}
+	if dialog.topIndex > dialog.shown()-visible {
+		dialog.topIndex = dialog.shown() - visible
+	}
+	if dialog.topIndex < 0 {
 		dialog.topIndex = 0
 	}
+}
 
-	cnt := len(dialog.options)
+func (dialog *SelectionDialog) Open() {
+	if !BaseDialogOpen(dialog) {
+		return
+	}
 
-	if dialog.maxVisibleOptions > 0 {
-		cnt = min(cnt, dialog.topIndex+dialog.maxVisibleOptions)
+	if len(dialog.query) > 0 {
+		dialog.drawContent(dialog.x+3, dialog.y+3, dialog.filterLabel(), dialog.theme.InactiveItem)
 	}
+	if dialog.shown() == 0 {
+		dialog.drawContent(dialog.x+3, dialog.y+4, "(none)", dialog.theme.InactiveItem)
+	}
+
+	dialog.scrollToSelection(dialog.visibleOptions())
+	cnt := min(dialog.shown(), dialog.topIndex+dialog.visibleOptions())
 
 	k := 0
-	for i := dialog.topIndex; i < cnt; i++ {
+	for pos := dialog.topIndex; pos < cnt; pos++ {
+		i := dialog.optionAt(pos)
 		style := dialog.theme.InactiveItem
+		matchStyle := dialog.theme.Match
 
 		if i == dialog.selectedIndex {
 			style = dialog.theme.ActiveItem
+			matchStyle = style.Highlight(matchStyle)
 		}
 
-		DrawString(dialog.x+3, dialog.y+4+k, fmt.Sprintf("* %s", dialog.options[i].Text), style)
+		option := dialog.options[i]
+		prefix := dialog.optionPrefix(option)
+		x := dialog.x + 3 + stringWidth(prefix)
+
+		dialog.drawContent(dialog.x+3, dialog.y+4+k, prefix, style)
+		if dialog.highlights == nil {
+			dialog.drawContent(x, dialog.y+4+k, option.Text, style)
+		} else {
+			dialog.drawHighlighted(x, dialog.y+4+k, option.Text, dialog.highlights[pos], style, matchStyle)
+		}
 		k++
 	}
 }
 
+// drawHighlighted draws text with the characters at the given rune offsets (in ascending order)
+// in matchStyle and the rest in style.
+func (dialog *SelectionDialog) drawHighlighted(x int, y int, text string, positions []int, style Style, matchStyle Style) {
+	offset := 0
+	graphemes := uniseg.NewGraphemes(text)
+	for graphemes.Next() {
+		end := offset + len(graphemes.Runes())
+
+		cellStyle := style
+		for len(positions) > 0 && positions[0] < end {
+			cellStyle = matchStyle
+			positions = positions[1:]
+		}
+
+		dialog.drawContent(x, y, graphemes.Str(), cellStyle)
+		x += graphemes.Width()
+		offset = end
+	}
+}
+
+// filterLabel returns the line showing the query, as "/query", cutting off the start of it (marked
+// with a "<") if it is too long to fit in the dialog.
+func (dialog *SelectionDialog) filterLabel() (label string) {
+	query := dialog.query
+	label = "/" + string(query)
+	for len(query) > 0 && stringWidth(label) > dialog.width-6 {
+		query = query[nextBoundary(graphemeBoundaries(query), 0):]
+		label = "/<" + string(query)
+	}
+	return label
+}
+
+// handleClose clears the filter, as Esc does, so that it is not still applied if the dialog is
+// opened again.
+func (dialog *SelectionDialog) handleClose() {
+	dialog.SetFilter("")
+}
+
 func (dialog *SelectionDialog) HandleEvent(event termbox.Event) (handled bool, shouldClose bool) {
+	filtering := dialog.filterMode != NoFilter
+	if filtering && len(dialog.query) > 0 && event.Type == termbox.EventKey && event.Ch == 0 && event.Key == termbox.KeyEsc {
+		dialog.SetFilter("")
+		return true, false
+	}
+
 	handled, shouldClose = BaseDialogHandleEvent(dialog, event)
 	if handled {
 		return
 	}
 
-	maxOption := len(dialog.options) - 1
+	selected := dialog.positionOf(dialog.selectedIndex)
+	maxPosition := dialog.shown() - 1
 
 	switch event.Type {
 	case termbox.EventKey:
+		if event.Ch != 0 {
+			if !filtering || event.Mod&termbox.ModAlt != 0 {
+				return false, false
+			}
+
+			dialog.SetFilter(string(append(dialog.query, unicode.ToLower(event.Ch))))
+			return true, false
+		}
+
+		if (event.Key == termbox.KeyBackspace || event.Key == termbox.KeyBackspace2) && len(dialog.query) > 0 {
+			dialog.SetFilter(string(dialog.query[:prevBoundary(graphemeBoundaries(dialog.query), len(dialog.query))]))
+			return true, false
+		}
+
+		if maxPosition < 0 {
+			// Nothing matches the filter, so there is nothing to move to or choose.
+			return event.Key == termbox.KeyEnter || event.Key == termbox.KeySpace, false
+		}
+
 		switch event.Key {
 		case termbox.KeyArrowUp:
-			if dialog.selectedIndex > 0 {
-				dialog.selectedIndex--
-				if (dialog.maxVisibleOptions > 0) && (dialog.selectedIndex < dialog.topIndex) {
-					dialog.topIndex--
-				}
-
+			if selected > 0 {
+				dialog.selectedIndex = dialog.optionAt(selected - 1)
 			}
 
 			return true, false
 
 		case termbox.KeyArrowDown:
-			if dialog.selectedIndex < maxOption {
-				dialog.selectedIndex++
-
-				if (dialog.maxVisibleOptions > 0) && (dialog.selectedIndex == dialog.topIndex+dialog.maxVisibleOptions) {
-					dialog.topIndex++
-				}
+			if selected < maxPosition {
+				dialog.selectedIndex = dialog.optionAt(selected + 1)
 			}
 
 			return true, false
 
 		case termbox.KeyHome:
-			dialog.selectedIndex = 0
+			dialog.selectedIndex = dialog.optionAt(0)
 			dialog.topIndex = 0
 			return true, false
 
 		case termbox.KeyEnd:
-			dialog.selectedIndex = maxOption
-			if dialog.maxVisibleOptions > 0 {
-				dialog.topIndex = maxOption - dialog.maxVisibleOptions + 1
-			}
+			dialog.selectedIndex = dialog.optionAt(maxPosition)
 			return true, false
 
 		case termbox.KeyEnter, termbox.KeySpace:
@@ -228,6 +478,9 @@ func (dialog *SelectionDialog) HandleEvent(event termbox.Event) (handled bool, s
 			if option.Callback != nil {
 				shouldClose = option.Callback(option)
 			}
+			if shouldClose {
+				dialog.result = Confirmed
+			}
 
 			return true, shouldClose
 		}
@@ -0,0 +1,46 @@
+package termdialog
+
+import (
+	"context"
+	"github.com/nsf/termbox-go"
+	"strings"
+	"testing"
+)
+
+func TestSelectionDialogFilterClearedOnClose(t *testing.T) {
+	screen := NewVirtualScreen(80, 24)
+	CurrentBackend = screen
+
+	var chosen string
+	choose := func(option *Option) bool {
+		chosen = option.Text
+		return true
+	}
+	dialog := NewSelectionDialog("Title", []*Option{
+		{Text: "alpha", Callback: choose},
+		{Text: "beta", Callback: choose},
+		{Text: "gamma", Callback: choose},
+	})
+	dialog.SetFilterMode(FuzzyFilter)
+
+	stack := NewDialogStack()
+	stack.Open(dialog)
+	screen.QueueString("be")
+	screen.QueueKey(termbox.KeyEnter)
+	stack.RunContext(context.Background())
+	if chosen != "beta" || stack.IsOpen(dialog) {
+		t.Fatalf("chose %q (dialog open %v), want beta and closed", chosen, stack.IsOpen(dialog))
+	}
+
+	stack.Open(dialog)
+	stack.Draw()
+	if dialog.GetFilter() != "" || dialog.shown() != 3 {
+		t.Errorf("reopened with filter %q showing %d options, want no filter and 3", dialog.GetFilter(), dialog.shown())
+	}
+	if strings.Contains(screen.String(), "/be") {
+		t.Errorf("old filter still drawn:\n%s", screen.String())
+	}
+	if dialog.GetSelectedOption().Text != "beta" {
+		t.Errorf("%q selected after reopening, want the last choice, beta", dialog.GetSelectedOption().Text)
+	}
+}
@@ -14,6 +14,15 @@ func (style Style) Reverse() (newStyle Style) {
 	return Style{style.BG, style.FG}
 }
 
+// Function Highlight returns the style for text highlighted with the given style, such as a
+// Theme's Match style, when it appears inside text drawn in this one. The colours of this style
+// are kept, so that the highlight is legible against it, and the attributes (bold, underline and
+// so on) of the highlight are added.
+func (style Style) Highlight(highlight Style) (newStyle Style) {
+	const attributes = termbox.AttrBold | termbox.AttrUnderline | termbox.AttrReverse
+	return Style{style.FG | highlight.FG&attributes, style.BG}
+}
+
 // Type Theme represents a GUI theme.
 type Theme struct {
 	Screen       Style // The style for the empty background region.
@@ -23,6 +32,8 @@ type Theme struct {
 	Title        Style // The style for the title text of dialogs.
 	InactiveItem Style // The style for inactive items and static text on dialogs.
 	ActiveItem   Style // The style for active items and widgets that can be interacted with.
+	Error        Style // The style for error messages, such as failed input validation.
+	Match        Style // The style for the characters of options that match the text typed to filter them.
 
 	HasShadow     bool // Whether to display a shadow behind dialogs. (keep this false, shadow rendering looks horrible at the moment)
 	ShadowOffsetX int  // The X offset of the shadow, relative to the dialog's coordinates.
@@ -38,6 +49,8 @@ var PlainTheme = &Theme{
 	Title:        Style{termbox.ColorBlack | termbox.AttrUnderline, termbox.ColorWhite},
 	InactiveItem: Style{termbox.ColorBlack, termbox.ColorWhite},
 	ActiveItem:   Style{termbox.ColorWhite, termbox.ColorRed},
+	Error:        Style{termbox.ColorRed | termbox.AttrBold, termbox.ColorWhite},
+	Match:        Style{termbox.ColorBlack | termbox.AttrBold | termbox.AttrUnderline, termbox.ColorWhite},
 
 	HasShadow:     false,
 	ShadowOffsetX: 2,
@@ -53,6 +66,8 @@ var WhiptailTheme = &Theme{
 	Title:        Style{termbox.ColorBlack | termbox.AttrUnderline, termbox.ColorWhite},
 	InactiveItem: Style{termbox.ColorBlack, termbox.ColorWhite},
 	ActiveItem:   Style{termbox.ColorWhite, termbox.ColorRed},
+	Error:        Style{termbox.ColorRed, termbox.ColorWhite},
+	Match:        Style{termbox.ColorRed | termbox.AttrBold, termbox.ColorWhite},
 
 	HasShadow:     true,
 	ShadowOffsetX: 1,
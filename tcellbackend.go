@@ -0,0 +1,206 @@
+package termdialog
+
+import (
+	"errors"
+	"github.com/gdamore/tcell/v2"
+	"github.com/nsf/termbox-go"
+)
+
+// Variable ErrScreenClosed is carried by the EventError event that a TcellBackend returns from
+// PollEvent after its screen has been finalised.
+var ErrScreenClosed = errors.New("termdialog: screen has been closed")
+
+// Type TcellBackend is a Backend that renders through tcell. Colours and attributes are given as
+// termbox attributes, as everywhere else in termdialog; colours created with
+// termbox.RGBToAttribute are drawn in truecolor where the terminal supports it. Input events are
+// translated into their termbox equivalents, so existing dialogs work unchanged.
+type TcellBackend struct {
+	screen tcell.Screen
+}
+
+// Function NewTcellBackend creates and initialises a tcell screen and returns a backend that
+// renders to it. Close must be called to restore the terminal.
+func NewTcellBackend() (backend *TcellBackend, err error) {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return nil, err
+	}
+
+	err = screen.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	screen.EnablePaste()
+	return &TcellBackend{screen: screen}, nil
+}
+
+// Function Screen returns the underlying tcell screen.
+func (backend *TcellBackend) Screen() (screen tcell.Screen) {
+	return backend.screen
+}
+
+// Function Close finalises the tcell screen, restoring the terminal to its previous state.
+func (backend *TcellBackend) Close() {
+	backend.screen.Fini()
+}
+
+func (backend *TcellBackend) SetCell(x int, y int, ch rune, fg termbox.Attribute, bg termbox.Attribute) {
+	backend.screen.SetContent(x, y, ch, nil, tcellStyle(fg, bg))
+}
+
+func (backend *TcellBackend) SetCellCombining(x int, y int, ch rune, combining []rune, fg termbox.Attribute, bg termbox.Attribute) {
+	backend.screen.SetContent(x, y, ch, combining, tcellStyle(fg, bg))
+}
+
+func (backend *TcellBackend) Size() (width int, height int) {
+	return backend.screen.Size()
+}
+
+func (backend *TcellBackend) Clear(fg termbox.Attribute, bg termbox.Attribute) (err error) {
+	backend.screen.Fill(' ', tcellStyle(fg, bg))
+	return nil
+}
+
+func (backend *TcellBackend) Flush() (err error) {
+	backend.screen.Show()
+	return nil
+}
+
+func (backend *TcellBackend) SetCursor(x int, y int) {
+	backend.screen.ShowCursor(x, y)
+}
+
+func (backend *TcellBackend) Interrupt() {
+	backend.screen.PostEvent(tcell.NewEventInterrupt(nil))
+}
+
+func (backend *TcellBackend) PollEvent() (event termbox.Event) {
+	for {
+		switch ev := backend.screen.PollEvent().(type) {
+		case nil:
+			return termbox.Event{Type: termbox.EventError, Err: ErrScreenClosed}
+
+		case *tcell.EventKey:
+			keyEvent, ok := termboxKeyEvent(ev)
+			if ok {
+				return keyEvent
+			}
+
+		case *tcell.EventResize:
+			width, height := ev.Size()
+			return termbox.Event{Type: termbox.EventResize, Width: width, Height: height}
+
+		case *tcell.EventInterrupt:
+			return termbox.Event{Type: termbox.EventInterrupt}
+
+		case *tcell.EventPaste:
+			if ev.Start() {
+				return termbox.Event{Type: EventPasteStart}
+			}
+			return termbox.Event{Type: EventPasteEnd}
+
+		case *tcell.EventError:
+			return termbox.Event{Type: termbox.EventError, Err: ev}
+		}
+	}
+}
+
+var tcellKeys = map[tcell.Key]termbox.Key{
+	tcell.KeyF1:     termbox.KeyF1,
+	tcell.KeyF2:     termbox.KeyF2,
+	tcell.KeyF3:     termbox.KeyF3,
+	tcell.KeyF4:     termbox.KeyF4,
+	tcell.KeyF5:     termbox.KeyF5,
+	tcell.KeyF6:     termbox.KeyF6,
+	tcell.KeyF7:     termbox.KeyF7,
+	tcell.KeyF8:     termbox.KeyF8,
+	tcell.KeyF9:     termbox.KeyF9,
+	tcell.KeyF10:    termbox.KeyF10,
+	tcell.KeyF11:    termbox.KeyF11,
+	tcell.KeyF12:    termbox.KeyF12,
+	tcell.KeyInsert: termbox.KeyInsert,
+	tcell.KeyDelete: termbox.KeyDelete,
+	tcell.KeyHome:   termbox.KeyHome,
+	tcell.KeyEnd:    termbox.KeyEnd,
+	tcell.KeyPgUp:   termbox.KeyPgup,
+	tcell.KeyPgDn:   termbox.KeyPgdn,
+	tcell.KeyUp:     termbox.KeyArrowUp,
+	tcell.KeyDown:   termbox.KeyArrowDown,
+	tcell.KeyLeft:   termbox.KeyArrowLeft,
+	tcell.KeyRight:  termbox.KeyArrowRight,
+}
+
+// termboxKeyEvent translates a tcell key event into a termbox one. It returns false for keys that
+// termbox has no equivalent for.
+func termboxKeyEvent(ev *tcell.EventKey) (event termbox.Event, ok bool) {
+	event.Type = termbox.EventKey
+	if ev.Modifiers()&tcell.ModAlt != 0 {
+		event.Mod = termbox.ModAlt
+	}
+
+	key := ev.Key()
+	switch {
+	case key == tcell.KeyRune:
+		// termbox reports the space bar as a key rather than as a character.
+		if ev.Rune() == ' ' {
+			event.Key = termbox.KeySpace
+		} else {
+			event.Ch = ev.Rune()
+		}
+
+	case key <= tcell.KeyDEL:
+		// Control keys share their ASCII codes in both libraries. Ctrl is passed on so that keys
+		// such as Ctrl+Enter can be told apart from Enter on terminals that report them.
+		event.Key = termbox.Key(key)
+		if ev.Modifiers()&tcell.ModCtrl != 0 {
+			event.Mod |= ModCtrl
+		}
+
+	default:
+		if ev.Modifiers()&tcell.ModCtrl != 0 {
+			event.Mod |= ModCtrl
+		}
+
+		event.Key, ok = tcellKeys[key]
+		return event, ok
+	}
+
+	return event, true
+}
+
+// tcellStyle converts a pair of termbox attributes into a tcell style.
+func tcellStyle(fg termbox.Attribute, bg termbox.Attribute) (style tcell.Style) {
+	style = tcell.StyleDefault.Foreground(tcellColor(fg)).Background(tcellColor(bg))
+
+	return style.
+		Bold(fg&termbox.AttrBold != 0).
+		Blink(fg&termbox.AttrBlink != 0).
+		Dim(fg&termbox.AttrDim != 0).
+		Underline(fg&termbox.AttrUnderline != 0).
+		Italic(fg&termbox.AttrCursive != 0).
+		Reverse(fg&termbox.AttrReverse != 0 || bg&termbox.AttrReverse != 0)
+}
+
+// tcellColor extracts the colour from a termbox attribute. Palette colours keep their termbox
+// numbering (offset by one, as termbox reserves zero for the default colour).
+func tcellColor(attr termbox.Attribute) (color tcell.Color) {
+	if isRGBAttribute(attr) {
+		r, g, b := termbox.AttributeToRGB(attr)
+		return tcell.NewRGBColor(int32(r), int32(g), int32(b))
+	}
+
+	index := int(attr & 0x1FF)
+	if index == 0 {
+		return tcell.ColorDefault
+	}
+
+	return tcell.PaletteColor(index - 1)
+}
+
+// isRGBAttribute reports whether attr was created by termbox.RGBToAttribute. RGB colours are
+// stored above all of the palette and attribute bits, so even black compares greater than any
+// non-RGB attribute.
+func isRGBAttribute(attr termbox.Attribute) (isRGB bool) {
+	return attr >= termbox.RGBToAttribute(0, 0, 0)
+}
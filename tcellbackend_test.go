@@ -0,0 +1,59 @@
+package termdialog
+
+import (
+	"context"
+	"github.com/gdamore/tcell/v2"
+	"github.com/nsf/termbox-go"
+	"testing"
+)
+
+func TestTermboxKeyEventKeepsCtrl(t *testing.T) {
+	tests := []struct {
+		name string
+		ev   *tcell.EventKey
+		key  termbox.Key
+		mod  termbox.Modifier
+	}{
+		{"Enter", tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), termbox.KeyEnter, 0},
+		{"Ctrl+Enter", tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModCtrl), termbox.KeyEnter, ModCtrl},
+		{"Alt+Enter", tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModAlt), termbox.KeyEnter, termbox.ModAlt},
+		{"Ctrl+Left", tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModCtrl), termbox.KeyArrowLeft, ModCtrl},
+	}
+
+	for _, test := range tests {
+		event, ok := termboxKeyEvent(test.ev)
+		if !ok || event.Type != termbox.EventKey || event.Key != test.key || event.Mod != test.mod {
+			t.Errorf("%s: got %+v (ok %v), want key %v with mod %v", test.name, event, ok, test.key, test.mod)
+		}
+	}
+}
+
+func TestTextAreaSubmitsOnCtrlEnter(t *testing.T) {
+	screen := NewVirtualScreen(80, 24)
+	CurrentBackend = screen
+
+	var got string
+	dialog := NewTextAreaDialog("Notes", 40, 5, "", func(value string, arg interface{}) bool {
+		got = value
+		return true
+	}, nil)
+	dialog.SetSubmitKey(termbox.KeyEnter, ModCtrl)
+
+	stack := NewDialogStack()
+	stack.Open(dialog)
+
+	for _, ev := range []*tcell.EventKey{
+		tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyRune, 'b', tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModCtrl),
+	} {
+		event, _ := termboxKeyEvent(ev)
+		screen.QueueEvent(event)
+	}
+	stack.RunContext(context.Background())
+
+	if dialog.GetResult() != Confirmed || got != "a\nb" {
+		t.Errorf("result %v with text %q, want %v with %q", dialog.GetResult(), got, Confirmed, "a\nb")
+	}
+}
@@ -0,0 +1,207 @@
+package termdialog
+
+import (
+	"github.com/nsf/termbox-go"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// escapeDelay is how long TermboxBackend waits after an Esc for the rest of an escape sequence.
+// A terminal sends the whole of a sequence at once, so anything that takes longer than this to
+// arrive was typed separately.
+const escapeDelay = 50 * time.Millisecond
+
+// termboxInput reads events from termbox and puts back together the ones that termbox splits up.
+//
+// termbox's InputAlt mode, the only way to have it report ModAlt, cannot deliver a lone Esc until
+// another key is pressed, which would make dialogs hard to close. In its default InputEsc mode,
+// Alt+key arrives as Esc followed by the key, and escape sequences it does not know (such as
+// "\x1b[1;5D" for Ctrl+Left) arrive as Esc followed by their characters. termboxInput stays in
+// InputEsc mode, but treats an Esc that is immediately followed by another key as Alt+key, and
+// decodes the xterm-style sequences for modified arrow and editing keys and the markers that
+// terminals put around bracketed pastes, dropping other unknown sequences rather than delivering
+// them as an Esc and some text.
+type termboxInput struct {
+	poll    func() termbox.Event // reads the next event; termbox.PollEvent if nil
+	pending chan termbox.Event   // receives the event being read in the background, if any
+	queue   []termbox.Event      // events that have been read but not yet returned
+}
+
+// read returns the next event from termbox. If wait is false and no event arrives within
+// escapeDelay, it gives up and returns false; the event is then returned by a later call.
+func (input *termboxInput) read(wait bool) (event termbox.Event, ok bool) {
+	if len(input.queue) > 0 {
+		event = input.queue[0]
+		input.queue = input.queue[1:]
+		return event, true
+	}
+
+	if input.pending == nil {
+		poll := input.poll
+		if poll == nil {
+			poll = termbox.PollEvent
+		}
+
+		pending := make(chan termbox.Event, 1)
+		go func() { pending <- poll() }()
+		input.pending = pending
+	}
+
+	if wait {
+		event = <-input.pending
+		input.pending = nil
+		return event, true
+	}
+
+	timer := time.NewTimer(escapeDelay)
+	defer timer.Stop()
+
+	select {
+	case event = <-input.pending:
+		input.pending = nil
+		return event, true
+	case <-timer.C:
+		return event, false
+	}
+}
+
+// unread puts events back to be returned by read, in order, before any others.
+func (input *termboxInput) unread(events ...termbox.Event) {
+	input.queue = append(append([]termbox.Event(nil), events...), input.queue...)
+}
+
+// isPlainKey returns whether event is a keypress with no modifiers.
+func isPlainKey(event termbox.Event) (plain bool) {
+	return event.Type == termbox.EventKey && event.Mod == 0
+}
+
+// Function PollEvent returns the next event, combining Esc with the keys that immediately follow
+// it.
+func (input *termboxInput) PollEvent() (event termbox.Event) {
+	for {
+		event, _ = input.read(true)
+		if !isPlainKey(event) || event.Ch != 0 || event.Key != termbox.KeyEsc {
+			return event
+		}
+
+		following, ok := input.read(false)
+		if !ok {
+			return event
+		}
+		if !isPlainKey(following) || (following.Ch == 0 && following.Key == termbox.KeyEsc) {
+			input.unread(following)
+			return event
+		}
+
+		if following.Ch != '[' && following.Ch != 'O' {
+			following.Mod |= termbox.ModAlt
+			return following
+		}
+
+		sequence, complete := input.readSequence()
+		if !complete {
+			// It was Alt+[ or Alt+O after all.
+			input.unread(sequence...)
+			following.Mod |= termbox.ModAlt
+			return following
+		}
+
+		decoded, ok := decodeSequence(sequence)
+		if ok {
+			return decoded
+		}
+		// Otherwise the sequence is dropped, and the next event is read instead.
+	}
+}
+
+// readSequence reads the characters of an escape sequence following "\x1b[" or "\x1bO", up to and
+// including its final character. It returns false if the sequence does not finish straight away,
+// along with the events it read.
+func (input *termboxInput) readSequence() (sequence []termbox.Event, complete bool) {
+	for {
+		event, ok := input.read(false)
+		if !ok {
+			return sequence, false
+		}
+		if !isPlainKey(event) || event.Ch < 0x20 || event.Ch > 0x7E {
+			input.unread(event)
+			return sequence, false
+		}
+
+		sequence = append(sequence, event)
+		if event.Ch >= 0x40 {
+			return sequence, true
+		}
+	}
+}
+
+// sequenceKeys maps the final characters of CSI sequences such as "\x1b[1;5D" to keys.
+var sequenceKeys = map[rune]termbox.Key{
+	'A': termbox.KeyArrowUp,
+	'B': termbox.KeyArrowDown,
+	'C': termbox.KeyArrowRight,
+	'D': termbox.KeyArrowLeft,
+	'H': termbox.KeyHome,
+	'F': termbox.KeyEnd,
+}
+
+// tildeKeys maps the numbers of CSI sequences such as "\x1b[3;5~" to keys.
+var tildeKeys = map[string]termbox.Key{
+	"1": termbox.KeyHome,
+	"2": termbox.KeyInsert,
+	"3": termbox.KeyDelete,
+	"4": termbox.KeyEnd,
+	"5": termbox.KeyPgup,
+	"6": termbox.KeyPgdn,
+	"7": termbox.KeyHome,
+	"8": termbox.KeyEnd,
+}
+
+// decodeSequence decodes the characters of an escape sequence read by readSequence, returning
+// false if it is not one for a modified arrow or editing key, or the start or end of a bracketed
+// paste.
+func decodeSequence(sequence []termbox.Event) (event termbox.Event, ok bool) {
+	runes := make([]rune, len(sequence))
+	for i, e := range sequence {
+		runes[i] = e.Ch
+	}
+
+	switch string(runes) {
+	case "200~":
+		return termbox.Event{Type: EventPasteStart}, true
+	case "201~":
+		return termbox.Event{Type: EventPasteEnd}, true
+	}
+
+	final := runes[len(runes)-1]
+	params := strings.Split(string(runes[:len(runes)-1]), ";")
+
+	event.Type = termbox.EventKey
+	if final == '~' {
+		event.Key, ok = tildeKeys[params[0]]
+	} else {
+		event.Key, ok = sequenceKeys[final]
+	}
+	if !ok {
+		return event, false
+	}
+
+	// The second parameter, if any, is one more than a bitmask of Shift (1), Alt (2), Ctrl (4)
+	// and Meta (8). termbox has no Shift modifier, so it is ignored, and Meta is treated as Alt.
+	if len(params) > 1 {
+		mask, err := strconv.Atoi(params[1])
+		if err != nil || mask < 1 {
+			return event, false
+		}
+		mask--
+		if mask&(2|8) != 0 {
+			event.Mod |= termbox.ModAlt
+		}
+		if mask&4 != 0 {
+			event.Mod |= ModCtrl
+		}
+	}
+
+	return event, true
+}
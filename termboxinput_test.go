@@ -0,0 +1,123 @@
+package termdialog
+
+import (
+	"context"
+	"github.com/nsf/termbox-go"
+	"testing"
+)
+
+// scriptedInput returns a termboxInput that reads the given events, as termbox would deliver them
+// in InputEsc mode, and then blocks.
+func scriptedInput(events ...termbox.Event) (input *termboxInput) {
+	ch := make(chan termbox.Event, len(events))
+	for _, event := range events {
+		ch <- event
+	}
+	return &termboxInput{poll: func() termbox.Event { return <-ch }}
+}
+
+func keyEvent(key termbox.Key) (event termbox.Event) {
+	return termbox.Event{Type: termbox.EventKey, Key: key}
+}
+
+func charEvents(str string) (events []termbox.Event) {
+	for _, c := range str {
+		events = append(events, termbox.Event{Type: termbox.EventKey, Ch: c})
+	}
+	return events
+}
+
+func concatEvents(lists ...[]termbox.Event) (events []termbox.Event) {
+	for _, list := range lists {
+		events = append(events, list...)
+	}
+	return events
+}
+
+func TestTermboxInputCombinesEscapes(t *testing.T) {
+	esc := keyEvent(termbox.KeyEsc)
+
+	tests := []struct {
+		name   string
+		events []termbox.Event
+		want   []termbox.Event
+	}{
+		{"lone Esc", []termbox.Event{esc}, []termbox.Event{esc}},
+		{"Esc Esc", []termbox.Event{esc, esc}, []termbox.Event{esc, esc}},
+		{"Alt+b", append([]termbox.Event{esc}, charEvents("bx")...), []termbox.Event{
+			{Type: termbox.EventKey, Ch: 'b', Mod: termbox.ModAlt},
+			{Type: termbox.EventKey, Ch: 'x'},
+		}},
+		{"Alt+Backspace", []termbox.Event{esc, keyEvent(termbox.KeyBackspace2)}, []termbox.Event{
+			{Type: termbox.EventKey, Key: termbox.KeyBackspace2, Mod: termbox.ModAlt},
+		}},
+		{"Ctrl+Left", append([]termbox.Event{esc}, charEvents("[1;5Dx")...), []termbox.Event{
+			{Type: termbox.EventKey, Key: termbox.KeyArrowLeft, Mod: ModCtrl},
+			{Type: termbox.EventKey, Ch: 'x'},
+		}},
+		{"Alt+Right", append([]termbox.Event{esc}, charEvents("[1;3C")...), []termbox.Event{
+			{Type: termbox.EventKey, Key: termbox.KeyArrowRight, Mod: termbox.ModAlt},
+		}},
+		{"Ctrl+Delete", append([]termbox.Event{esc}, charEvents("[3;5~")...), []termbox.Event{
+			{Type: termbox.EventKey, Key: termbox.KeyDelete, Mod: ModCtrl},
+		}},
+		{"unknown sequence", append(append([]termbox.Event{esc}, charEvents("[99z")...), charEvents("x")...), []termbox.Event{
+			{Type: termbox.EventKey, Ch: 'x'},
+		}},
+		{"Alt+[", append([]termbox.Event{esc}, charEvents("[")...), []termbox.Event{
+			{Type: termbox.EventKey, Ch: '[', Mod: termbox.ModAlt},
+		}},
+		{"bracketed paste", concatEvents([]termbox.Event{esc}, charEvents("[200~a"), []termbox.Event{keyEvent(termbox.KeyEnter), esc}, charEvents("[201~")), []termbox.Event{
+			{Type: EventPasteStart},
+			{Type: termbox.EventKey, Ch: 'a'},
+			{Type: termbox.EventKey, Key: termbox.KeyEnter},
+			{Type: EventPasteEnd},
+		}},
+	}
+
+	for _, test := range tests {
+		input := scriptedInput(test.events...)
+		for i, want := range test.want {
+			got := input.PollEvent()
+			if got != want {
+				t.Errorf("%s: event %d is %+v, want %+v", test.name, i, got, want)
+			}
+		}
+	}
+}
+
+// termboxScreen is a VirtualScreen whose events are read through a termboxInput, as they are by
+// TermboxBackend.
+type termboxScreen struct {
+	*VirtualScreen
+	input *termboxInput
+}
+
+func (screen termboxScreen) PollEvent() (event termbox.Event) {
+	return screen.input.PollEvent()
+}
+
+func TestTermboxPasteDoesNotSubmit(t *testing.T) {
+	virtual := NewVirtualScreen(80, 24)
+	CurrentBackend = termboxScreen{virtual, &termboxInput{poll: virtual.PollEvent}}
+
+	var values []string
+	dialog := NewInputDialog("Title", "Value:", 20, "", func(value string, arg interface{}) bool {
+		values = append(values, value)
+		return true
+	}, nil)
+	stack := NewDialogStack()
+	stack.Open(dialog)
+
+	// The terminal's bytes for pasting "ab\ncd" and pressing Enter, as termbox reports them.
+	esc := keyEvent(termbox.KeyEsc)
+	enter := keyEvent(termbox.KeyEnter)
+	for _, event := range concatEvents([]termbox.Event{esc}, charEvents("[200~ab"), []termbox.Event{enter}, charEvents("cd"), []termbox.Event{esc}, charEvents("[201~"), []termbox.Event{enter}) {
+		virtual.QueueEvent(event)
+	}
+	stack.RunContext(context.Background())
+
+	if len(values) != 1 || values[0] != "abcd" {
+		t.Errorf("submitted %q, want just %q", values, "abcd")
+	}
+}
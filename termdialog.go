@@ -3,7 +3,7 @@ package termdialog
 
 import (
 	"fmt"
-	"github.com/nsf/termbox-go"
+	"github.com/rivo/uniseg"
 )
 
 const (
@@ -20,43 +20,93 @@ const (
 	BOX_CROSS     rune = 0x253C // Cross
 )
 
+// minContentWidth is the narrowest that the content of a dialog may be truncated to before the
+// dialog gives up and asks for a larger terminal.
+const minContentWidth = 4
+
+// setCell draws a single cell, ignoring positions that lie outside the screen.
+func setCell(x int, y int, ch rune, style Style) {
+	windowWidth, windowHeight := CurrentBackend.Size()
+	if x < 0 || y < 0 || x >= windowWidth || y >= windowHeight {
+		return
+	}
+
+	CurrentBackend.SetCell(x, y, ch, style.FG, style.BG)
+}
+
+// setCluster draws a grapheme cluster into a single cell, including any combining marks if the
+// backend supports them.
+func setCluster(x int, y int, cluster []rune, style Style) {
+	combiningBackend, ok := CurrentBackend.(CombiningBackend)
+	if !ok || len(cluster) == 1 {
+		setCell(x, y, cluster[0], style)
+		return
+	}
+
+	windowWidth, windowHeight := CurrentBackend.Size()
+	if x < 0 || y < 0 || x >= windowWidth || y >= windowHeight {
+		return
+	}
+
+	combiningBackend.SetCellCombining(x, y, cluster[0], cluster[1:], style.FG, style.BG)
+}
+
 // Function DrawBox draws a box on the screen.
 func DrawBox(x int, y int, width int, height int, style Style) {
-	fg := style.FG
-	bg := style.BG
-
 	xmax := x + width - 1
 	ymax := y + height - 1
 
-	termbox.SetCell(x, y, BOX_CORNER_TL, fg, bg)
-	termbox.SetCell(xmax, y, BOX_CORNER_TR, fg, bg)
-	termbox.SetCell(x, ymax, BOX_CORNER_BL, fg, bg)
-	termbox.SetCell(xmax, ymax, BOX_CORNER_BR, fg, bg)
+	setCell(x, y, BOX_CORNER_TL, style)
+	setCell(xmax, y, BOX_CORNER_TR, style)
+	setCell(x, ymax, BOX_CORNER_BL, style)
+	setCell(xmax, ymax, BOX_CORNER_BR, style)
 
 	for i := x + 1; i <= xmax-1; i++ {
-		termbox.SetCell(i, y, BOX_HOZ, fg, bg)
-		termbox.SetCell(i, ymax, BOX_HOZ, fg, bg)
+		setCell(i, y, BOX_HOZ, style)
+		setCell(i, ymax, BOX_HOZ, style)
 	}
 
 	for i := y + 1; i <= ymax-1; i++ {
-		termbox.SetCell(x, i, BOX_VERT, fg, bg)
-		termbox.SetCell(xmax, i, BOX_VERT, fg, bg)
+		setCell(x, i, BOX_VERT, style)
+		setCell(xmax, i, BOX_VERT, style)
 	}
 }
 
 // Function DrawString draws the specified text onto the screen.
 func DrawString(x int, y int, str string, style Style) {
+	windowWidth, windowHeight := CurrentBackend.Size()
+	drawClippedString(x, y, str, style, 0, 0, windowWidth, windowHeight)
+}
+
+// drawClippedString draws text like DrawString, but only the parts that lie inside the rectangle
+// from (minX, minY) inclusive to (maxX, maxY) exclusive.
+func drawClippedString(x int, y int, str string, style Style, minX int, minY int, maxX int, maxY int) {
 	startX := x
 
-	for _, c := range str {
-		if c == '\r' {
-			x = startX
-		} else if c == '\n' {
-			y++
-		} else {
-			termbox.SetCell(x, y, c, style.FG, style.BG)
-			x++
+	graphemes := uniseg.NewGraphemes(str)
+	for graphemes.Next() {
+		cluster := graphemes.Runes()
+
+		if cluster[0] == '\r' || cluster[0] == '\n' {
+			// "\r\n" is a single grapheme cluster, so look at every rune.
+			for _, c := range cluster {
+				if c == '\r' {
+					x = startX
+				} else if c == '\n' {
+					y++
+				}
+			}
+			continue
+		}
+
+		// Combining marks are drawn in the same cell as the character they modify, if the
+		// backend supports it. Wide characters take up two cells, and are only drawn if both of
+		// them are visible.
+		width := graphemes.Width()
+		if width > 0 && x >= minX && x+width <= maxX && y >= minY && y < maxY {
+			setCluster(x, y, cluster, style)
 		}
+		x += width
 	}
 }
 
@@ -64,7 +114,7 @@ func DrawString(x int, y int, str string, style Style) {
 func Fill(x int, y int, width int, height int, ch rune, style Style) {
 	for i := 0; i < width; i++ {
 		for j := 0; j < height; j++ {
-			termbox.SetCell(x+i, y+j, ch, style.FG, style.BG)
+			setCell(x+i, y+j, ch, style)
 		}
 	}
 }
@@ -0,0 +1,168 @@
+// Package termdialogtest provides helpers for snapshot-testing termdialog layouts against golden
+// files.
+//
+// A typical test renders a DialogStack onto a termdialog.VirtualScreen and compares the result
+// with a file under testdata. The screen must be made the current backend before any dialogs are
+// opened, as dialogs lay themselves out against the backend's size:
+//
+//	screen := termdialog.NewVirtualScreen(80, 24)
+//	termdialog.CurrentBackend = screen
+//	stack := termdialog.NewDialogStack()
+//	stack.Open(termdialog.NewMessageDialog("Title", "Hello"))
+//	termdialogtest.AssertSnapshot(t, stack, screen, "hello")
+//
+// Running "go test -termdialogtest.update" rewrites the golden files from the current output
+// instead of comparing against them.
+package termdialogtest
+
+import (
+	"flag"
+	"fmt"
+	"github.com/kierdavis/termdialog"
+	"github.com/nsf/termbox-go"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The flag is namespaced so that it does not clash with an -update flag defined by the tests that
+// import this package.
+var update = flag.Bool("termdialogtest.update", false, "rewrite golden files in testdata instead of comparing against them")
+
+// Function Snapshot draws stack onto screen and returns the plain text of the screen. Screen is
+// made the current backend if it is not already.
+func Snapshot(stack *termdialog.DialogStack, screen *termdialog.VirtualScreen) (text string) {
+	termdialog.CurrentBackend = screen
+	stack.Draw()
+	return screen.String()
+}
+
+// Function StyledSnapshot is like Snapshot, but annotates the text with the style of every cell.
+// The text is followed by a grid of the same size in which each cell is replaced by a letter
+// identifying its style, and then by a legend mapping each letter to its attributes. Styles are
+// lettered a-z, then A-Z, then 0-9; if a screen uses even more styles than that, the rest are all
+// shown as "#".
+func StyledSnapshot(stack *termdialog.DialogStack, screen *termdialog.VirtualScreen) (text string) {
+	text = Snapshot(stack, screen)
+	width, height := screen.Size()
+
+	type style struct {
+		fg termbox.Attribute
+		bg termbox.Attribute
+	}
+
+	letters := make(map[style]rune)
+	var legend []string
+	grid := make([]string, height)
+
+	for y := 0; y < height; y++ {
+		row := make([]rune, width)
+		for x := 0; x < width; x++ {
+			cell := screen.Cell(x, y)
+			s := style{cell.Fg, cell.Bg}
+			letter, ok := letters[s]
+			if !ok {
+				if len(letters) < len(styleLetters) {
+					letter = styleLetters[len(letters)]
+					legend = append(legend, fmt.Sprintf("%c: fg=%s bg=%s", letter, AttributeName(s.fg), AttributeName(s.bg)))
+				} else {
+					letter = '#'
+					if len(letters) == len(styleLetters) {
+						legend = append(legend, "#: other styles")
+					}
+				}
+				letters[s] = letter
+			}
+
+			row[x] = letter
+		}
+
+		grid[y] = string(row)
+	}
+
+	return text + "\n--\n" + strings.Join(grid, "\n") + "\n--\n" + strings.Join(legend, "\n")
+}
+
+// styleLetters are the letters that StyledSnapshot assigns to styles, in order.
+var styleLetters = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
+
+var colorNames = []string{
+	"default", "black", "red", "green", "yellow", "blue", "magenta", "cyan", "white",
+	"darkgray", "lightred", "lightgreen", "lightyellow", "lightblue", "lightmagenta", "lightcyan", "lightgray",
+}
+
+var attrNames = []struct {
+	attr termbox.Attribute
+	name string
+}{
+	{termbox.AttrBold, "bold"},
+	{termbox.AttrBlink, "blink"},
+	{termbox.AttrHidden, "hidden"},
+	{termbox.AttrDim, "dim"},
+	{termbox.AttrUnderline, "underline"},
+	{termbox.AttrCursive, "cursive"},
+	{termbox.AttrReverse, "reverse"},
+}
+
+// Function AttributeName returns a human-readable name for a termbox attribute, such as
+// "black+underline".
+func AttributeName(attr termbox.Attribute) (name string) {
+	parts := make([]string, 0, 2)
+	color := attr & 0x1FF
+
+	if int(color) < len(colorNames) {
+		parts = append(parts, colorNames[color])
+	} else {
+		parts = append(parts, fmt.Sprintf("color%d", color))
+	}
+
+	for _, a := range attrNames {
+		if attr&a.attr != 0 {
+			parts = append(parts, a.name)
+		}
+	}
+
+	return strings.Join(parts, "+")
+}
+
+// Function AssertGolden compares got with the contents of testdata/<name>.golden, failing t if they
+// differ. When the -termdialogtest.update flag is set, the golden file is written instead.
+func AssertGolden(t testing.TB, name string, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err == nil {
+			err = os.WriteFile(path, []byte(got), 0644)
+		}
+		if err != nil {
+			t.Fatalf("updating golden file: %s", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -termdialogtest.update to create it): %s", err)
+	}
+
+	if got != string(want) {
+		t.Errorf("output differs from %s (run with -termdialogtest.update to accept it)\n--- got:\n%s\n--- want:\n%s", path, got, want)
+	}
+}
+
+// Function AssertSnapshot renders stack onto screen and compares the plain text with a golden file.
+func AssertSnapshot(t testing.TB, stack *termdialog.DialogStack, screen *termdialog.VirtualScreen, name string) {
+	t.Helper()
+	AssertGolden(t, name, Snapshot(stack, screen))
+}
+
+// Function AssertStyledSnapshot renders stack onto screen and compares the style-annotated text
+// with a golden file.
+func AssertStyledSnapshot(t testing.TB, stack *termdialog.DialogStack, screen *termdialog.VirtualScreen, name string) {
+	t.Helper()
+	AssertGolden(t, name, StyledSnapshot(stack, screen))
+}
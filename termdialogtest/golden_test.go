@@ -0,0 +1,166 @@
+package termdialogtest
+
+import (
+	"flag"
+	"fmt"
+	"github.com/kierdavis/termdialog"
+	"github.com/nsf/termbox-go"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// newScreen makes an 80x24 virtual screen the current backend and returns it with an empty stack.
+func newScreen() (screen *termdialog.VirtualScreen, stack *termdialog.DialogStack) {
+	screen = termdialog.NewVirtualScreen(80, 24)
+	termdialog.CurrentBackend = screen
+	return screen, termdialog.NewDialogStack()
+}
+
+func TestMessageDialogSnapshot(t *testing.T) {
+	screen, stack := newScreen()
+	stack.Open(termdialog.NewMessageDialog("Title", "Hello, world!"))
+	AssertSnapshot(t, stack, screen, "messagedialog")
+}
+
+func TestInputDialogSnapshot(t *testing.T) {
+	screen, stack := newScreen()
+	stack.Open(termdialog.NewInputDialog("Title", "Name:", 20, "abc", nil, nil))
+	AssertSnapshot(t, stack, screen, "inputdialog")
+}
+
+func TestSelectionDialogSnapshot(t *testing.T) {
+	screen, stack := newScreen()
+	dialog := termdialog.NewSelectionDialog("Title", nil)
+	dialog.AddOption(&termdialog.Option{Text: "First"})
+	dialog.AddOption(&termdialog.Option{Text: "Second"})
+	dialog.AddOption(&termdialog.Option{Text: "Third"})
+	dialog.SetSelectedIndex(1)
+	stack.Open(dialog)
+	AssertStyledSnapshot(t, stack, screen, "selectiondialog")
+}
+
+// chdirTemp runs the rest of the test in a new empty directory.
+func chdirTemp(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// recorder is a testing.TB that records failures instead of reporting them.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// run calls f with r in a separate goroutine, so that Fatalf can stop it.
+func (r *recorder) run(f func(tb testing.TB)) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f(r)
+	}()
+	<-done
+}
+
+func TestAssertGolden(t *testing.T) {
+	chdirTemp(t)
+	defer func(old bool) { *update = old }(*update)
+
+	// Without -termdialogtest.update, a missing golden file is an error.
+	*update = false
+	r := &recorder{TB: t}
+	r.run(func(tb testing.TB) { AssertGolden(tb, "example", "one\n") })
+	if len(r.failures) != 1 || !strings.Contains(r.failures[0], "-termdialogtest.update") {
+		t.Fatalf("missing golden file: got failures %q, want one suggesting -termdialogtest.update", r.failures)
+	}
+
+	// With -termdialogtest.update, the golden file is written and nothing fails.
+	*update = true
+	r = &recorder{TB: t}
+	r.run(func(tb testing.TB) { AssertGolden(tb, "example", "one\n") })
+	if len(r.failures) != 0 {
+		t.Fatalf("updating: got failures %q, want none", r.failures)
+	}
+	data, err := os.ReadFile(filepath.Join("testdata", "example.golden"))
+	if err != nil || string(data) != "one\n" {
+		t.Fatalf("golden file contains %q (error %v), want %q", data, err, "one\n")
+	}
+
+	// Without -termdialogtest.update again, matching output passes and differing output fails.
+	*update = false
+	r = &recorder{TB: t}
+	r.run(func(tb testing.TB) { AssertGolden(tb, "example", "one\n") })
+	if len(r.failures) != 0 {
+		t.Fatalf("matching output: got failures %q, want none", r.failures)
+	}
+
+	r = &recorder{TB: t}
+	r.run(func(tb testing.TB) { AssertGolden(tb, "example", "two\n") })
+	if len(r.failures) != 1 || !strings.Contains(r.failures[0], "two") {
+		t.Fatalf("differing output: got failures %q, want one showing the output", r.failures)
+	}
+}
+
+func TestUpdateFlagLeavesCommonNameFree(t *testing.T) {
+	// Test packages importing termdialogtest often define their own -update flag.
+	if flag.Lookup("update") != nil {
+		t.Error("termdialogtest registers -update, which clashes with the importing test's own flag")
+	}
+	if flag.Lookup("termdialogtest.update") == nil {
+		t.Error("termdialogtest.update flag is not registered")
+	}
+}
+
+// paletteDialog draws a row of cells, each in a different style.
+type paletteDialog struct {
+	termdialog.BaseDialog
+	styles int
+}
+
+func (dialog *paletteDialog) Open() {
+	for i := 0; i < dialog.styles; i++ {
+		termdialog.DrawString(i, 0, "x", termdialog.Style{FG: termbox.Attribute(i + 1), BG: termbox.ColorBlack})
+	}
+}
+
+func (dialog *paletteDialog) HandleEvent(event termbox.Event) (handled bool, shouldClose bool) {
+	return false, false
+}
+
+func TestStyledSnapshotLegendBounded(t *testing.T) {
+	screen, stack := newScreen()
+	stack.Open(&paletteDialog{styles: 70})
+
+	parts := strings.Split(StyledSnapshot(stack, screen), "\n--\n")
+	row := []rune(strings.Split(parts[1], "\n")[0])
+
+	if row[0] != 'a' || row[25] != 'z' || row[26] != 'A' || row[61] != '9' {
+		t.Errorf("styles lettered %q, want a-z, A-Z, 0-9", string(row[:62]))
+	}
+	if string(row[62:70]) != "########" {
+		t.Errorf("extra styles lettered %q, want all #", string(row[62:70]))
+	}
+	if !strings.HasSuffix(parts[2], "\n#: other styles") {
+		t.Errorf("legend does not end with the entry for #:\n%s", parts[2])
+	}
+}
@@ -0,0 +1,55 @@
+package termdialog
+
+import (
+	"github.com/rivo/uniseg"
+)
+
+// stringWidth returns the number of terminal cells that str occupies.
+func stringWidth(str string) (width int) {
+	return uniseg.StringWidth(str)
+}
+
+// graphemeBoundaries returns the offsets into runes at which each grapheme cluster (i.e. each
+// user-perceived character) starts, followed by len(runes).
+func graphemeBoundaries(runes []rune) (boundaries []int) {
+	boundaries = make([]int, 0, len(runes)+1)
+	pos := 0
+
+	graphemes := uniseg.NewGraphemes(string(runes))
+	for graphemes.Next() {
+		boundaries = append(boundaries, pos)
+		pos += len(graphemes.Runes())
+	}
+
+	return append(boundaries, pos)
+}
+
+// prevBoundary returns the grapheme boundary before pos, or 0 if there is none.
+func prevBoundary(boundaries []int, pos int) (prev int) {
+	for i := len(boundaries) - 1; i >= 0; i-- {
+		if boundaries[i] < pos {
+			return boundaries[i]
+		}
+	}
+	return 0
+}
+
+// nextBoundary returns the grapheme boundary after pos, or the last boundary if there is none.
+func nextBoundary(boundaries []int, pos int) (next int) {
+	for _, b := range boundaries {
+		if b > pos {
+			return b
+		}
+	}
+	return boundaries[len(boundaries)-1]
+}
+
+// snapToBoundary returns the first grapheme boundary at or after pos.
+func snapToBoundary(boundaries []int, pos int) (snapped int) {
+	for _, b := range boundaries {
+		if b >= pos {
+			return b
+		}
+	}
+	return boundaries[len(boundaries)-1]
+}
@@ -0,0 +1,515 @@
+package termdialog
+
+import (
+	"fmt"
+	"github.com/nsf/termbox-go"
+	"strings"
+	"unicode"
+)
+
+/*
+  +--------------------------+
+  |                          |
+  |  Title                   |
+  |                          |
+  |  xxxxxxxxxxxxxxxxxxxx    |
+  |  xxxxxxxxxxxxxx          |
+  |                          |
+  |  Ctrl+D: done            |
+  |                          |
+  +--------------------------+
+*/
+
+// Type TextAreaDialog represents a dialog in which the user can enter several lines of text. Long
+// lines are word-wrapped to the width of the text area, which scrolls vertically as necessary.
+// Enter starts a new line; the text is submitted with a separate key, Ctrl+D by default.
+type TextAreaDialog struct {
+	BaseDialog
+	valueWidth   int // the number of cells in each line of the text area
+	visibleLines int // the number of lines of the text area
+	value        []rune
+	cursor       int // index into value; always at the start of a grapheme cluster
+	scroll       int // the first wrapped line that is visible
+	goalX        int // the column Up and Down try to keep the cursor in, or -1 to use the current one
+	submitKey    termbox.Key
+	submitMod    termbox.Modifier
+	callback     func(string, interface{}) bool
+	arg          interface{}
+}
+
+// Function NewTextAreaDialog creates and returns a new text area dialog with a text area of the
+// given size. The callback is called with the text when it is submitted, and works as it does for
+// InputDialog.
+func NewTextAreaDialog(title string, valueWidth int, visibleLines int, valueInit string, callback func(string, interface{}) bool, arg interface{}) (dialog *TextAreaDialog) {
+	dialog = &TextAreaDialog{
+		valueWidth:   valueWidth,
+		visibleLines: visibleLines,
+		goalX:        -1,
+		submitKey:    termbox.KeyCtrlD,
+		callback:     callback,
+		arg:          arg,
+	}
+
+	dialog.BaseDialog.title = title
+	dialog.BaseDialog.metricsDirty = true
+	dialog.BaseDialog.theme = DefaultTheme
+	dialog.SetValue(valueInit)
+	return dialog
+}
+
+func (dialog *TextAreaDialog) GetValueWidth() (valueWidth int) {
+	return dialog.valueWidth
+}
+
+func (dialog *TextAreaDialog) SetValueWidth(valueWidth int) {
+	dialog.valueWidth = valueWidth
+	dialog.metricsDirty = true
+}
+
+func (dialog *TextAreaDialog) GetVisibleLines() (visibleLines int) {
+	return dialog.visibleLines
+}
+
+func (dialog *TextAreaDialog) SetVisibleLines(visibleLines int) {
+	dialog.visibleLines = visibleLines
+	dialog.metricsDirty = true
+}
+
+func (dialog *TextAreaDialog) GetValue() (value string) {
+	return string(dialog.value)
+}
+
+// Function SetValue sets the text, moving the cursor to the end of it. Line breaks may be given as
+// "\n", "\r\n" or "\r"; tabs are replaced with spaces.
+func (dialog *TextAreaDialog) SetValue(value string) {
+	dialog.value = []rune(cleanText(value))
+	dialog.cursor = len(dialog.value)
+	dialog.goalX = -1
+}
+
+func (dialog *TextAreaDialog) GetSubmitKey() (key termbox.Key, mod termbox.Modifier) {
+	return dialog.submitKey, dialog.submitMod
+}
+
+// Function SetSubmitKey sets the key that submits the text, together with any modifiers that must
+// be held down with it (e.g. termbox.KeyEnter with ModCtrl for Ctrl+Enter). The default is Ctrl+D.
+// Ctrl+Enter can only be used with TcellBackend, on terminals that report it; termbox cannot tell
+// it apart from Enter.
+func (dialog *TextAreaDialog) SetSubmitKey(key termbox.Key, mod termbox.Modifier) {
+	dialog.submitKey = key
+	dialog.submitMod = mod
+	dialog.metricsDirty = true
+}
+
+func (dialog *TextAreaDialog) GetCallback() (callback func(string, interface{}) bool) {
+	return dialog.callback
+}
+
+func (dialog *TextAreaDialog) SetCallback(callback func(string, interface{}) bool) {
+	dialog.callback = callback
+}
+
+func (dialog *TextAreaDialog) GetCallbackArg() (arg interface{}) {
+	return dialog.arg
+}
+
+func (dialog *TextAreaDialog) SetCallbackArg(arg interface{}) {
+	dialog.arg = arg
+}
+
+// hint returns the line shown under the text area saying how to submit the text.
+func (dialog *TextAreaDialog) hint() (hint string) {
+	return keyName(dialog.submitKey, dialog.submitMod) + ": done"
+}
+
+func (dialog *TextAreaDialog) CalcMetrics() {
+	maxWidth := dialog.valueWidth
+	if stringWidth(dialog.BaseDialog.title) > maxWidth {
+		maxWidth = stringWidth(dialog.BaseDialog.title)
+	}
+	if stringWidth(dialog.hint()) > maxWidth {
+		maxWidth = stringWidth(dialog.hint())
+	}
+
+	dialog.width = 6 + maxWidth             // 6 = "|  " + "  |"
+	dialog.height = 8 + dialog.visibleLines // 8 = Borders, padding, title and hint
+
+	dialog.fitToScreen(6+minContentWidth, 9)
+
+	dialog.metricsDirty = false
+}
+
+// textWidth returns the number of cells in each line of the text area, which is less than the
+// value width if the dialog has been shrunk to fit on the screen.
+func (dialog *TextAreaDialog) textWidth() (width int) {
+	width = dialog.valueWidth
+	if room := dialog.width - 6; room < width {
+		width = room
+	}
+	if width < 2 {
+		width = 2
+	}
+	return width
+}
+
+// textLines returns the number of lines of the text area that fit in the dialog.
+func (dialog *TextAreaDialog) textLines() (lines int) {
+	lines = dialog.visibleLines
+	if room := dialog.height - 8; room < lines {
+		lines = room
+	}
+	if lines < 1 {
+		lines = 1
+	}
+	return lines
+}
+
+func (dialog *TextAreaDialog) Open() {
+	if !BaseDialogOpen(dialog) {
+		return
+	}
+
+	style := dialog.theme.ActiveItem
+	width := dialog.textWidth()
+	nLines := dialog.textLines()
+	lines := wrapText(dialog.value, width-1)
+
+	dialog.scrollToCursor(lines)
+
+	Fill(dialog.x+3, dialog.y+4, width, nLines, ' ', style)
+	for k := 0; k < nLines && dialog.scroll+k < len(lines); k++ {
+		line := lines[dialog.scroll+k]
+		dialog.drawContent(dialog.x+3, dialog.y+4+k, string(dialog.value[line.start:line.end]), style)
+	}
+
+	// Show which way the text continues, if it does not all fit.
+	if dialog.scroll > 0 {
+		dialog.drawContent(dialog.x+3+width-1, dialog.y+4, "^", style)
+	}
+	if dialog.scroll+nLines < len(lines) {
+		dialog.drawContent(dialog.x+3+width-1, dialog.y+3+nLines, "v", style)
+	}
+
+	dialog.drawContent(dialog.x+3, dialog.y+5+nLines, dialog.hint(), dialog.theme.InactiveItem)
+}
+
+// textLine is one line of a text area as displayed: value[start:end], after word wrapping.
+type textLine struct {
+	start int
+	end   int
+	soft  bool // whether the line was wrapped, rather than ending at a line break or the end of the text
+}
+
+// wrapText splits value into lines at line breaks and, where a line is wider than width, between
+// words. Words that are wider than width on their own are split wherever necessary. Spaces at the
+// point where a line is wrapped stay at the end of it, even if they do not fit.
+func wrapText(value []rune, width int) (lines []textLine) {
+	start := 0
+	for start <= len(value) {
+		end := start
+		for end < len(value) && value[end] != '\n' {
+			end++
+		}
+
+		lines = append(lines, wrapParagraph(value, start, end, width)...)
+		start = end + 1
+	}
+	return lines
+}
+
+// wrapParagraph wraps value[start:end], which contains no line breaks.
+func wrapParagraph(value []rune, start int, end int, width int) (lines []textLine) {
+	boundaries := graphemeBoundaries(value[start:end])
+	lineStart := start
+	lineWidth := 0
+	breakAt := -1 // where the line can be broken, i.e. after the last space in it
+
+	for i := 0; i < len(boundaries)-1; i++ {
+		pos, next := start+boundaries[i], start+boundaries[i+1]
+		w := stringWidth(string(value[pos:next]))
+
+		if lineWidth+w > width && pos > lineStart && value[pos] != ' ' {
+			cut := pos
+			if breakAt > lineStart {
+				cut = breakAt
+			}
+
+			lines = append(lines, textLine{lineStart, cut, true})
+			lineStart = cut
+			lineWidth = stringWidth(string(value[cut:pos]))
+			breakAt = -1
+		}
+
+		lineWidth += w
+		if value[pos] == ' ' {
+			breakAt = next
+		}
+	}
+
+	return append(lines, textLine{lineStart, end, false})
+}
+
+// lineOf returns the index of the line the given position is displayed on.
+func lineOf(lines []textLine, pos int) (index int) {
+	for index = len(lines) - 1; index > 0; index-- {
+		if lines[index].start <= pos {
+			break
+		}
+	}
+	return index
+}
+
+// lineEnd returns the last position the cursor can take on a line. The end of a wrapped line is
+// the start of the next one, so the cursor stops before its last character instead.
+func (dialog *TextAreaDialog) lineEnd(boundaries []int, line textLine) (pos int) {
+	if line.soft {
+		return prevBoundary(boundaries, line.end)
+	}
+	return line.end
+}
+
+// column returns the column the cursor is displayed in.
+func (dialog *TextAreaDialog) column(lines []textLine) (x int) {
+	line := lines[lineOf(lines, dialog.cursor)]
+	return stringWidth(string(dialog.value[line.start:dialog.cursor]))
+}
+
+// positionAt returns the position on a line that is displayed at, or just before, column x.
+func (dialog *TextAreaDialog) positionAt(boundaries []int, line textLine, x int) (pos int) {
+	limit := dialog.lineEnd(boundaries, line)
+	pos = line.start
+	col := 0
+	for pos < limit {
+		next := nextBoundary(boundaries, pos)
+		w := stringWidth(string(dialog.value[pos:next]))
+		if col+w > x {
+			break
+		}
+		col += w
+		pos = next
+	}
+	return pos
+}
+
+// scrollToCursor adjusts the scroll position so that the cursor's line is visible.
+func (dialog *TextAreaDialog) scrollToCursor(lines []textLine) {
+	nLines := dialog.textLines()
+	line := lineOf(lines, dialog.cursor)
+
+	if line < dialog.scroll {
+		dialog.scroll = line
+	}
+	if line >= dialog.scroll+nLines {
+		dialog.scroll = line - nLines + 1
+	}
+	if dialog.scroll > len(lines)-nLines {
+		dialog.scroll = len(lines) - nLines
+	}
+	if dialog.scroll < 0 {
+		dialog.scroll = 0
+	}
+}
+
+// moveLines moves the cursor up (if n is negative) or down by n lines, keeping it as close as
+// possible to the column it was in before a run of vertical movements began.
+func (dialog *TextAreaDialog) moveLines(n int) {
+	lines := wrapText(dialog.value, dialog.textWidth()-1)
+	if dialog.goalX < 0 {
+		dialog.goalX = dialog.column(lines)
+	}
+
+	target := lineOf(lines, dialog.cursor) + n
+	if target < 0 {
+		target = 0
+	}
+	if target >= len(lines) {
+		target = len(lines) - 1
+	}
+
+	dialog.cursor = dialog.positionAt(graphemeBoundaries(dialog.value), lines[target], dialog.goalX)
+}
+
+// Function CursorPosition returns the screen position of the text cursor.
+func (dialog *TextAreaDialog) CursorPosition() (x int, y int, visible bool) {
+	lines := wrapText(dialog.value, dialog.textWidth()-1)
+	dialog.scrollToCursor(lines)
+
+	x = dialog.x + 3 + dialog.column(lines)
+	y = dialog.y + 4 + lineOf(lines, dialog.cursor) - dialog.scroll
+	visible = !dialog.tooSmall && x < dialog.x+3+dialog.textWidth()
+	return x, y, visible
+}
+
+// insert inserts text at the cursor.
+func (dialog *TextAreaDialog) insert(text string) {
+	runes := []rune(text)
+
+	value := make([]rune, 0, len(dialog.value)+len(runes))
+	value = append(value, dialog.value[:dialog.cursor]...)
+	value = append(value, runes...)
+	value = append(value, dialog.value[dialog.cursor:]...)
+
+	dialog.value = value
+	dialog.cursor = snapToBoundary(graphemeBoundaries(value), dialog.cursor+len(runes))
+}
+
+// remove deletes the runes between from and to.
+func (dialog *TextAreaDialog) remove(from int, to int) {
+	dialog.value = append(dialog.value[:from], dialog.value[to:]...)
+	if dialog.cursor > to {
+		dialog.cursor -= to - from
+	} else if dialog.cursor > from {
+		dialog.cursor = from
+	}
+}
+
+// isSubmitKey returns whether event is the key that submits the text.
+func (dialog *TextAreaDialog) isSubmitKey(event termbox.Event) (isSubmit bool) {
+	return event.Ch == 0 && event.Key == dialog.submitKey && event.Mod&dialog.submitMod == dialog.submitMod
+}
+
+func (dialog *TextAreaDialog) HandleEvent(event termbox.Event) (handled bool, shouldClose bool) {
+	handled, shouldClose = BaseDialogHandleEvent(dialog, event)
+	if handled {
+		return
+	}
+
+	if event.Type != termbox.EventKey {
+		return false, false
+	}
+
+	if dialog.isSubmitKey(event) {
+		shouldClose = true
+		if dialog.callback != nil {
+			shouldClose = dialog.callback(string(dialog.value), dialog.arg)
+		}
+		if shouldClose {
+			dialog.result = Confirmed
+		}
+
+		return true, shouldClose
+	}
+
+	if event.Ch != 0 {
+		if event.Mod&termbox.ModAlt != 0 {
+			return false, false
+		}
+
+		dialog.insert(string(event.Ch))
+		dialog.goalX = -1
+		return true, false
+	}
+
+	boundaries := graphemeBoundaries(dialog.value)
+	lines := wrapText(dialog.value, dialog.textWidth()-1)
+	line := lines[lineOf(lines, dialog.cursor)]
+	toEnds := event.Mod&ModCtrl != 0
+
+	switch event.Key {
+	case termbox.KeyArrowUp:
+		dialog.moveLines(-1)
+		return true, false
+
+	case termbox.KeyArrowDown:
+		dialog.moveLines(1)
+		return true, false
+
+	case termbox.KeyPgup:
+		dialog.scroll -= dialog.textLines()
+		dialog.moveLines(-dialog.textLines())
+		return true, false
+
+	case termbox.KeyPgdn:
+		dialog.scroll += dialog.textLines()
+		dialog.moveLines(dialog.textLines())
+		return true, false
+
+	case termbox.KeyEnter:
+		dialog.insert("\n")
+
+	case termbox.KeySpace:
+		dialog.insert(" ")
+
+	case termbox.KeyBackspace, termbox.KeyBackspace2:
+		dialog.remove(prevBoundary(boundaries, dialog.cursor), dialog.cursor)
+
+	case termbox.KeyDelete:
+		dialog.remove(dialog.cursor, nextBoundary(boundaries, dialog.cursor))
+
+	case termbox.KeyArrowLeft:
+		dialog.cursor = prevBoundary(boundaries, dialog.cursor)
+
+	case termbox.KeyArrowRight:
+		dialog.cursor = nextBoundary(boundaries, dialog.cursor)
+
+	case termbox.KeyHome:
+		if toEnds {
+			dialog.cursor = 0
+		} else {
+			dialog.cursor = line.start
+		}
+
+	case termbox.KeyEnd:
+		if toEnds {
+			dialog.cursor = len(dialog.value)
+		} else {
+			dialog.cursor = dialog.lineEnd(boundaries, line)
+		}
+
+	default:
+		return false, false
+	}
+
+	dialog.goalX = -1
+	return true, false
+}
+
+// Function HandlePaste inserts pasted text at the cursor in one go, keeping its line breaks.
+func (dialog *TextAreaDialog) HandlePaste(text string) (handled bool, shouldClose bool) {
+	dialog.insert(cleanText(text))
+	dialog.goalX = -1
+	return true, false
+}
+
+// cleanText normalises line breaks to "\n", replaces tabs with spaces and drops other control
+// characters.
+func cleanText(text string) (clean string) {
+	text = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text)
+	return strings.Map(func(c rune) rune {
+		switch {
+		case c == '\n':
+			return c
+		case c == '\t':
+			return ' '
+		case unicode.IsControl(c):
+			return -1
+		}
+		return c
+	}, text)
+}
+
+// keyName returns a human-readable name for a key, such as "Ctrl+D".
+func keyName(key termbox.Key, mod termbox.Modifier) (name string) {
+	switch {
+	case key >= termbox.KeyCtrlA && key <= termbox.KeyCtrlZ && key != termbox.KeyTab && key != termbox.KeyEnter && key != termbox.KeyBackspace:
+		name = fmt.Sprintf("Ctrl+%c", 'A'+rune(key-termbox.KeyCtrlA))
+	case key == termbox.KeyEnter:
+		name = "Enter"
+	case key == termbox.KeyTab:
+		name = "Tab"
+	case key == termbox.KeyEsc:
+		name = "Esc"
+	case key >= termbox.KeyF12 && key <= termbox.KeyF1:
+		name = fmt.Sprintf("F%d", termbox.KeyF1-key+1)
+	default:
+		name = "Submit key"
+	}
+
+	if mod&termbox.ModAlt != 0 {
+		name = "Alt+" + name
+	}
+	if mod&ModCtrl != 0 {
+		name = "Ctrl+" + name
+	}
+	return name
+}
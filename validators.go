@@ -0,0 +1,83 @@
+package termdialog
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Type Validator represents a function that checks the value of an input dialog. It returns nil if
+// the value is acceptable, or an error whose message is shown to the user otherwise.
+type Validator func(value string) error
+
+// Function NonEmpty is a Validator that rejects values consisting only of whitespace.
+func NonEmpty(value string) (err error) {
+	if strings.TrimSpace(value) == "" {
+		return errors.New("a value is required")
+	}
+	return nil
+}
+
+// Function IntRange returns a Validator that accepts decimal integers between min and max
+// inclusive.
+func IntRange(min int, max int) (validator Validator) {
+	return func(value string) error {
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return errors.New("not a whole number")
+		}
+		if n < min || n > max {
+			return fmt.Errorf("must be between %d and %d", min, max)
+		}
+		return nil
+	}
+}
+
+// Function MatchRegexp returns a Validator that accepts values matching re. If the value does not
+// match, message is shown to the user.
+func MatchRegexp(re *regexp.Regexp, message string) (validator Validator) {
+	return func(value string) error {
+		if !re.MatchString(value) {
+			return errors.New(message)
+		}
+		return nil
+	}
+}
+
+// Function IPAddress is a Validator that accepts IPv4 and IPv6 addresses.
+func IPAddress(value string) (err error) {
+	if net.ParseIP(strings.TrimSpace(value)) == nil {
+		return errors.New("not a valid IP address")
+	}
+	return nil
+}
+
+// Function ExistingFile is a Validator that accepts the path of an existing file (but not a
+// directory).
+func ExistingFile(value string) (err error) {
+	info, err := os.Stat(value)
+	if err != nil {
+		return errors.New("file does not exist")
+	}
+	if info.IsDir() {
+		return errors.New("is a directory")
+	}
+	return nil
+}
+
+// Function AllOf returns a Validator that applies each of validators in turn, returning the first
+// error.
+func AllOf(validators ...Validator) (validator Validator) {
+	return func(value string) error {
+		for _, v := range validators {
+			if err := v(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,220 @@
+package termdialog
+
+import (
+	"errors"
+	"github.com/nsf/termbox-go"
+	"strings"
+	"sync"
+)
+
+// Variable ErrNoMoreEvents is carried by the EventError event that a VirtualScreen returns from
+// PollEvent once its queue of scripted events has been used up.
+var ErrNoMoreEvents = errors.New("termdialog: no more scripted events")
+
+// Type VirtualScreen is an in-memory Backend. It records drawn cells in a grid and replays a queue
+// of scripted events, so that a DialogStack can be driven without a terminal (e.g. from go test).
+// Once the queue is empty, PollEvent returns an EventError carrying ErrNoMoreEvents, which causes
+// DialogStack.Run to return. Events may be queued from any goroutine. Combining marks are kept
+// with the cells they were drawn in.
+type VirtualScreen struct {
+	width          int
+	height         int
+	back           []termbox.Cell
+	front          []termbox.Cell
+	backCombining  [][]rune
+	frontCombining [][]rune
+	cursorX        int
+	cursorY        int
+	events         []termbox.Event
+	flushes        int
+	mutex          sync.Mutex // protects events
+}
+
+// Function NewVirtualScreen creates and returns a new virtual screen of the given size.
+func NewVirtualScreen(width int, height int) (screen *VirtualScreen) {
+	screen = &VirtualScreen{
+		cursorX: -1,
+		cursorY: -1,
+	}
+
+	screen.resize(width, height)
+	return screen
+}
+
+func (screen *VirtualScreen) resize(width int, height int) {
+	screen.width = width
+	screen.height = height
+	screen.back = make([]termbox.Cell, width*height)
+	screen.front = make([]termbox.Cell, width*height)
+	screen.backCombining = make([][]rune, width*height)
+	screen.frontCombining = make([][]rune, width*height)
+
+	for i := range screen.back {
+		screen.back[i].Ch = ' '
+		screen.front[i].Ch = ' '
+	}
+}
+
+func (screen *VirtualScreen) SetCell(x int, y int, ch rune, fg termbox.Attribute, bg termbox.Attribute) {
+	if x < 0 || y < 0 || x >= screen.width || y >= screen.height {
+		return
+	}
+
+	screen.back[y*screen.width+x] = termbox.Cell{Ch: ch, Fg: fg, Bg: bg}
+	screen.backCombining[y*screen.width+x] = nil
+}
+
+func (screen *VirtualScreen) SetCellCombining(x int, y int, ch rune, combining []rune, fg termbox.Attribute, bg termbox.Attribute) {
+	screen.SetCell(x, y, ch, fg, bg)
+	if x < 0 || y < 0 || x >= screen.width || y >= screen.height {
+		return
+	}
+
+	screen.backCombining[y*screen.width+x] = append([]rune(nil), combining...)
+}
+
+func (screen *VirtualScreen) Size() (width int, height int) {
+	return screen.width, screen.height
+}
+
+func (screen *VirtualScreen) Clear(fg termbox.Attribute, bg termbox.Attribute) (err error) {
+	for i := range screen.back {
+		screen.back[i] = termbox.Cell{Ch: ' ', Fg: fg, Bg: bg}
+		screen.backCombining[i] = nil
+	}
+
+	return nil
+}
+
+func (screen *VirtualScreen) Flush() (err error) {
+	copy(screen.front, screen.back)
+	copy(screen.frontCombining, screen.backCombining)
+	screen.flushes++
+	return nil
+}
+
+func (screen *VirtualScreen) PollEvent() (event termbox.Event) {
+	screen.mutex.Lock()
+	if len(screen.events) == 0 {
+		screen.mutex.Unlock()
+		return termbox.Event{Type: termbox.EventError, Err: ErrNoMoreEvents}
+	}
+
+	event = screen.events[0]
+	screen.events = screen.events[1:]
+	screen.mutex.Unlock()
+
+	if event.Type == termbox.EventResize {
+		screen.resize(event.Width, event.Height)
+	}
+
+	return event
+}
+
+func (screen *VirtualScreen) SetCursor(x int, y int) {
+	screen.cursorX = x
+	screen.cursorY = y
+}
+
+func (screen *VirtualScreen) Interrupt() {
+	screen.QueueEvent(termbox.Event{Type: termbox.EventInterrupt})
+}
+
+// Function Cell returns the cell at the given position, as of the last call to Flush.
+func (screen *VirtualScreen) Cell(x int, y int) (cell termbox.Cell) {
+	return screen.front[y*screen.width+x]
+}
+
+// Function Combining returns the combining marks drawn in the cell at the given position, as of the
+// last call to Flush.
+func (screen *VirtualScreen) Combining(x int, y int) (combining []rune) {
+	return screen.frontCombining[y*screen.width+x]
+}
+
+// Function Cursor returns the position of the cursor, or (-1, -1) if it is hidden.
+func (screen *VirtualScreen) Cursor() (x int, y int) {
+	return screen.cursorX, screen.cursorY
+}
+
+// Function Flushes returns the number of times Flush has been called.
+func (screen *VirtualScreen) Flushes() (n int) {
+	return screen.flushes
+}
+
+// Function Line returns the text of row y as of the last call to Flush, with trailing spaces
+// removed. As on a terminal, the cell following a wide character is hidden by it.
+func (screen *VirtualScreen) Line(y int) (line string) {
+	row := make([]rune, 0, screen.width)
+	for x := 0; x < screen.width; x++ {
+		ch := screen.front[y*screen.width+x].Ch
+		row = append(row, ch)
+		row = append(row, screen.frontCombining[y*screen.width+x]...)
+
+		if stringWidth(string(ch)) == 2 {
+			x++
+		}
+	}
+
+	return strings.TrimRight(string(row), " ")
+}
+
+// Function String returns the text of the whole screen as of the last call to Flush, one line per
+// row.
+func (screen *VirtualScreen) String() (str string) {
+	lines := make([]string, screen.height)
+	for y := range lines {
+		lines[y] = screen.Line(y)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Function QueueEvent appends an event to the queue returned by PollEvent.
+func (screen *VirtualScreen) QueueEvent(event termbox.Event) {
+	screen.mutex.Lock()
+	screen.events = append(screen.events, event)
+	screen.mutex.Unlock()
+}
+
+// Function QueueKey queues a keypress of a special key, such as termbox.KeyEnter.
+func (screen *VirtualScreen) QueueKey(key termbox.Key) {
+	screen.QueueEvent(termbox.Event{Type: termbox.EventKey, Key: key})
+}
+
+// Function QueueString queues one keypress per character of str. As with termbox, spaces are
+// delivered as termbox.KeySpace rather than as a character.
+func (screen *VirtualScreen) QueueString(str string) {
+	for _, c := range str {
+		if c == ' ' {
+			screen.QueueKey(termbox.KeySpace)
+		} else {
+			screen.QueueEvent(termbox.Event{Type: termbox.EventKey, Ch: c})
+		}
+	}
+}
+
+// Function QueuePaste queues a bracketed paste of text: an EventPasteStart, one keypress per
+// character (with line breaks and tabs delivered as termbox.KeyEnter and termbox.KeyTab) and an
+// EventPasteEnd.
+func (screen *VirtualScreen) QueuePaste(text string) {
+	screen.QueueEvent(termbox.Event{Type: EventPasteStart})
+	for _, c := range text {
+		switch c {
+		case '\n':
+			screen.QueueKey(termbox.KeyEnter)
+		case '\t':
+			screen.QueueKey(termbox.KeyTab)
+		case ' ':
+			screen.QueueKey(termbox.KeySpace)
+		default:
+			screen.QueueEvent(termbox.Event{Type: termbox.EventKey, Ch: c})
+		}
+	}
+	screen.QueueEvent(termbox.Event{Type: EventPasteEnd})
+}
+
+// Function QueueResize queues a resize event. The screen takes on the new size when the event is
+// returned from PollEvent.
+func (screen *VirtualScreen) QueueResize(width int, height int) {
+	screen.QueueEvent(termbox.Event{Type: termbox.EventResize, Width: width, Height: height})
+}